@@ -0,0 +1,85 @@
+package ably
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// authMode selects how a Request is authenticated.
+type authMode int
+
+const (
+	authDefault authMode = iota // Use the configured credentials, see authorize.
+	authKey                     // Use the API key as basic auth.
+	authNone                    // Send no credentials, e.g. for signed token requests.
+)
+
+// authorize sets the Authorization header for req from the configured
+// credentials. When several are present the first match wins:
+//
+//  1. BearerToken - an Ably Token or Ably JWT, sent as token auth.
+//  2. APIKey      - a full "keyName:keySecret" API key, exchanged for an
+//     Ably Token which is cached and renewed before it expires.
+//  3. BasicAuth   - pre-encoded basic auth credentials, sent verbatim.
+//
+// Requests with a non-default auth mode override this.
+func (c *Client) authorize(ctx context.Context, r *Request, req *http.Request) error {
+	switch {
+	case r.auth == authNone:
+	case r.auth == authKey:
+		if _, _, err := SplitKey(c.cfg.APIKey); err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.cfg.APIKey)))
+	case c.cfg.BearerToken != "":
+		req.Header.Set("Authorization", bearer(c.cfg.BearerToken))
+	case c.cfg.APIKey != "":
+		details, err := c.token(ctx)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", bearer(details.Token))
+	case c.cfg.BasicAuth != "":
+		req.Header.Set("Authorization", fmt.Sprintf("Basic %s", c.cfg.BasicAuth))
+	}
+	return nil
+}
+
+// AuthMethod describes how requests are authenticated: "token", "api_key"
+// for tokens minted from the API key, "basic" or "none".
+func (c *Client) AuthMethod() string {
+	switch {
+	case c.cfg.BearerToken != "":
+		return "token"
+	case c.cfg.APIKey != "":
+		return "api_key"
+	case c.cfg.BasicAuth != "":
+		return "basic"
+	}
+	return "none"
+}
+
+// usesMintedToken reports whether r is authenticated with a token minted
+// from the configured API key.
+func (c *Client) usesMintedToken(r *Request) bool {
+	return r.auth == authDefault && c.cfg.BearerToken == "" && c.cfg.APIKey != ""
+}
+
+// bearer formats token for the Authorization header; Ably expects the token
+// to be base64 encoded.
+func bearer(token string) string {
+	return "Bearer " + base64.StdEncoding.EncodeToString([]byte(token))
+}
+
+// SplitKey splits an Ably API key of the form "appId.keyId:keySecret" into
+// its key name and secret.
+func SplitKey(key string) (keyName, keySecret string, err error) {
+	keyName, keySecret, ok := strings.Cut(key, ":")
+	if !ok || keyName == "" || keySecret == "" || !strings.Contains(keyName, ".") {
+		return "", "", fmt.Errorf("invalid API key: expected the form appId.keyId:keySecret")
+	}
+	return keyName, keySecret, nil
+}
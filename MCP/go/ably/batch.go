@@ -0,0 +1,82 @@
+package ably
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/platform-api/mcp-server/models"
+)
+
+// errorCodeBatchErrors is the code of the error Ably answers a batch
+// publish with when publishing to some of the channels failed.
+const errorCodeBatchErrors = 40020
+
+// BatchSpec publishes the same messages to each of a set of channels.
+type BatchSpec struct {
+	Channels []string         `json:"channels"`
+	Messages []models.Message `json:"messages"`
+}
+
+// BatchResult is the outcome of a batch publish on one channel. Exactly one
+// of Messageid and Error is set.
+type BatchResult struct {
+	Channel   string        `json:"channel"`
+	Messageid string        `json:"messageId,omitempty"`
+	Error     *models.Error `json:"error,omitempty"`
+}
+
+// batchErrorBody is the body of a partially failed batch publish before
+// protocol version 2.
+type batchErrorBody struct {
+	BatchResponse []BatchResult `json:"batchResponse"`
+}
+
+// batchResponseItem is an element of a batch publish response. Before
+// protocol version 2 it is the result on one channel; since then it holds
+// the results of a whole spec.
+type batchResponseItem struct {
+	BatchResult
+	Results []BatchResult `json:"results"`
+}
+
+// PublishBatch publishes each spec in a single request to Ably's batch
+// publish endpoint. When only some channels fail, the per-channel results
+// are returned without an error.
+func (c *Client) PublishBatch(ctx context.Context, specs []BatchSpec) ([]BatchResult, error) {
+	idempotent := true
+	for _, spec := range specs {
+		idempotent = idempotent && HasIDs(spec.Messages)
+	}
+	resp, err := c.Do(ctx, &Request{
+		Method:     http.MethodPost,
+		Path:       Path("messages"),
+		Body:       specs,
+		Idempotent: idempotent,
+	})
+
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.Info.Code == errorCodeBatchErrors {
+		var body batchErrorBody
+		if decodeBody(apiErr.Header, apiErr.Body, &body) == nil && len(body.BatchResponse) > 0 {
+			return body.BatchResponse, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var items []batchResponseItem
+	if err := resp.Decode(&items); err != nil {
+		return nil, err
+	}
+	var results []BatchResult
+	for _, item := range items {
+		if item.Results != nil {
+			results = append(results, item.Results...)
+		} else {
+			results = append(results, item.BatchResult)
+		}
+	}
+	return results, nil
+}
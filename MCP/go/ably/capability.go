@@ -0,0 +1,372 @@
+package ably
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Operations maps each capability operation Ably recognises to a short
+// description of what it grants.
+var Operations = map[string]string{
+	"*":                    "all operations",
+	"publish":              "publish messages",
+	"subscribe":            "subscribe to messages and presence",
+	"presence":             "enter, update and leave the presence set",
+	"history":              "retrieve message and presence history",
+	"stats":                "retrieve application statistics",
+	"channel-metadata":     "query channel status and occupancy",
+	"push-subscribe":       "subscribe devices to push notifications",
+	"push-admin":           "manage push device registrations and subscriptions for any device",
+	"privileged-headers":   "set privileged message extras",
+	"object-subscribe":     "subscribe to LiveObjects updates",
+	"object-publish":       "create and update LiveObjects",
+	"annotation-subscribe": "subscribe to message annotations",
+	"annotation-publish":   "publish and delete message annotations",
+	"message-update-own":   "update messages the client published",
+	"message-update-any":   "update messages published by any client",
+	"message-delete-own":   "delete messages the client published",
+	"message-delete-any":   "delete messages published by any client",
+}
+
+// Capability maps channel name patterns to the operations allowed on them.
+// A pattern is a channel name, "*" to match every channel or "ns:*" to match
+// every channel of the namespace ns. It may be qualified: "[meta]log" is the
+// log channel qualified with meta, and a "[*]" qualifier matches channels
+// with any qualifier or none.
+type Capability map[string][]string
+
+// CapabilityRule describes a single pattern of a Capability in words.
+type CapabilityRule struct {
+	Resource   string   `json:"resource"`
+	Matches    string   `json:"matches"`
+	Operations []string `json:"operations"`
+	Grants     []string `json:"grants"`
+}
+
+// ParseCapability reads a capability given either as JSON text, as Ably
+// returns it in TokenDetails, or as a decoded JSON object. The result is
+// validated and normalized.
+func ParseCapability(v interface{}) (Capability, error) {
+	return parseCapability(v, true)
+}
+
+// ParseKeyCapability reads the capability of a key like ParseCapability, but
+// keeps operations missing from Operations: Ably may grant operations newer
+// than this server, and dropping or rejecting them would misstate what the
+// key allows.
+func ParseKeyCapability(v interface{}) (Capability, error) {
+	return parseCapability(v, false)
+}
+
+func parseCapability(v interface{}, knownOnly bool) (Capability, error) {
+	var raw []byte
+	switch val := v.(type) {
+	case nil:
+		return nil, fmt.Errorf("capability is empty")
+	case string:
+		raw = []byte(val)
+	default:
+		var err error
+		if raw, err = json.Marshal(val); err != nil {
+			return nil, fmt.Errorf("failed to encode capability: %w", err)
+		}
+	}
+	var c Capability
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("capability must map channel patterns to lists of operations: %w", err)
+	}
+	if err := c.validate(knownOnly); err != nil {
+		return nil, err
+	}
+	return c.normalize(), nil
+}
+
+// Validate reports the first problem found in c: an empty or malformed
+// pattern, a pattern without operations, or an operation Ably does not
+// recognise.
+func (c Capability) Validate() error {
+	return c.validate(true)
+}
+
+func (c Capability) validate(knownOnly bool) error {
+	if len(c) == 0 {
+		return fmt.Errorf("capability is empty")
+	}
+	for resource, ops := range c {
+		if resource == "" {
+			return fmt.Errorf("capability contains an empty channel pattern")
+		}
+		if err := validateResource(resource); err != nil {
+			return err
+		}
+		if len(ops) == 0 {
+			return fmt.Errorf("capability for %q lists no operations", resource)
+		}
+		for _, op := range ops {
+			if op == "" {
+				return fmt.Errorf("capability for %q contains an empty operation", resource)
+			}
+			if _, ok := Operations[op]; !ok && knownOnly {
+				return fmt.Errorf("capability for %q contains unknown operation %q", resource, op)
+			}
+		}
+	}
+	return nil
+}
+
+// validateResource checks the qualifier and wildcard of a pattern.
+func validateResource(resource string) error {
+	qualifier, name := splitQualifier(resource)
+	if strings.HasPrefix(resource, "[") {
+		if !strings.Contains(resource, "]") {
+			return fmt.Errorf("capability pattern %q has an unterminated qualifier", resource)
+		}
+		if qualifier == "" {
+			return fmt.Errorf("capability pattern %q has an empty qualifier", resource)
+		}
+		if qualifier != "*" && strings.Contains(qualifier, "*") {
+			return fmt.Errorf("capability pattern %q uses * in a qualifier other than [*]", resource)
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("capability pattern %q names no channel", resource)
+	}
+	if _, wildcard := wildcardPrefix(name); !wildcard && strings.Contains(name, "*") {
+		return fmt.Errorf("capability pattern %q uses * other than as \"*\" or \"namespace:*\"", resource)
+	}
+	return nil
+}
+
+// Allows reports whether c permits op on the named channel.
+func (c Capability) Allows(channel, op string) bool {
+	for resource, ops := range c {
+		if coversResource(resource, channel) && (contains(ops, "*") || contains(ops, op)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSubsetOf reports whether everything c grants is also granted by other.
+func (c Capability) IsSubsetOf(other Capability) bool {
+	return len(c.Exceeding(other)) == 0
+}
+
+// Exceeding returns the operations of c that other does not grant.
+func (c Capability) Exceeding(other Capability) Capability {
+	result := Capability{}
+	for resource, ops := range c {
+		for _, op := range ops {
+			if !other.covers(resource, op) {
+				result[resource] = append(result[resource], op)
+			}
+		}
+	}
+	return result.normalize()
+}
+
+// Intersect returns the capability granted by both c and other, which is
+// what Ably issues when a token requests c from a key with capability other.
+func (c Capability) Intersect(other Capability) Capability {
+	result := Capability{}
+	for resource, ops := range c {
+		for otherResource, otherOps := range other {
+			var narrower string
+			switch {
+			case coversResource(otherResource, resource):
+				narrower = resource
+			case coversResource(resource, otherResource):
+				narrower = otherResource
+			default:
+				continue
+			}
+			result[narrower] = append(result[narrower], intersectOps(ops, otherOps)...)
+		}
+	}
+	for resource, ops := range result {
+		if len(ops) == 0 {
+			delete(result, resource)
+		}
+	}
+	return result.normalize()
+}
+
+// Explain describes each pattern of c, sorted by pattern.
+func (c Capability) Explain() []CapabilityRule {
+	rules := make([]CapabilityRule, 0, len(c))
+	for _, resource := range c.resources() {
+		ops := c[resource]
+		grants := make([]string, 0, len(ops))
+		for _, op := range ops {
+			grant, ok := Operations[op]
+			if !ok {
+				grant = "an operation this server does not know"
+			}
+			grants = append(grants, fmt.Sprintf("%s: %s", op, grant))
+		}
+		rules = append(rules, CapabilityRule{
+			Resource:   resource,
+			Matches:    describeResource(resource),
+			Operations: ops,
+			Grants:     grants,
+		})
+	}
+	return rules
+}
+
+// Map converts c to the representation used by models.TokenRequest.
+func (c Capability) Map() map[string]interface{} {
+	m := make(map[string]interface{}, len(c))
+	for resource, ops := range c {
+		m[resource] = ops
+	}
+	return m
+}
+
+// String returns c as canonical JSON text.
+func (c Capability) String() string {
+	b, _ := json.Marshal(c.normalize())
+	return string(b)
+}
+
+func (c Capability) covers(resource, op string) bool {
+	for otherResource, ops := range c {
+		if coversResource(otherResource, resource) && (contains(ops, "*") || contains(ops, op)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Capability) resources() []string {
+	resources := make([]string, 0, len(c))
+	for resource := range c {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	return resources
+}
+
+// normalize sorts and deduplicates operations, collapsing any list that
+// contains "*" to just "*".
+func (c Capability) normalize() Capability {
+	out := make(Capability, len(c))
+	for resource, ops := range c {
+		if contains(ops, "*") {
+			out[resource] = []string{"*"}
+			continue
+		}
+		seen := make(map[string]bool, len(ops))
+		var unique []string
+		for _, op := range ops {
+			if !seen[op] {
+				seen[op] = true
+				unique = append(unique, op)
+			}
+		}
+		sort.Strings(unique)
+		out[resource] = unique
+	}
+	return out
+}
+
+// coversResource reports whether every channel matched by inner, which may
+// be a pattern or a plain channel name, is also matched by outer.
+func coversResource(outer, inner string) bool {
+	if outer == inner {
+		return true
+	}
+	outerQualifier, outerName := splitQualifier(outer)
+	innerQualifier, innerName := splitQualifier(inner)
+	// "[*]" matches any qualifier or none; otherwise qualified channels are
+	// only matched by patterns with the same qualifier
+	if outerQualifier != "*" && outerQualifier != innerQualifier {
+		return false
+	}
+	if outerName == innerName {
+		return true
+	}
+	prefix, wildcard := wildcardPrefix(outerName)
+	if !wildcard {
+		return false
+	}
+	if innerPrefix, innerWildcard := wildcardPrefix(innerName); innerWildcard {
+		return strings.HasPrefix(innerPrefix, prefix)
+	}
+	return strings.HasPrefix(innerName, prefix)
+}
+
+// splitQualifier splits a pattern such as "[meta]log" into its qualifier
+// and channel name. Unqualified patterns have an empty qualifier.
+func splitQualifier(pattern string) (qualifier, name string) {
+	if !strings.HasPrefix(pattern, "[") {
+		return "", pattern
+	}
+	end := strings.Index(pattern, "]")
+	if end < 0 {
+		return "", pattern
+	}
+	return pattern[1:end], pattern[end+1:]
+}
+
+// wildcardPrefix returns the prefix of the channel names matched by a
+// wildcard, "" for "*" and "ns:" for "ns:*", and whether name is a
+// wildcard at all. Ably recognises no other use of "*".
+func wildcardPrefix(name string) (string, bool) {
+	if name == "*" {
+		return "", true
+	}
+	namespace, ok := strings.CutSuffix(name, ":*")
+	if !ok || namespace == "" || strings.Contains(namespace, "*") {
+		return "", false
+	}
+	return namespace + ":", true
+}
+
+func intersectOps(a, b []string) []string {
+	switch {
+	case contains(a, "*"):
+		return b
+	case contains(b, "*"):
+		return a
+	}
+	var ops []string
+	for _, op := range a {
+		if contains(b, op) {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+func describeResource(resource string) string {
+	qualifier, name := splitQualifier(resource)
+	var desc string
+	switch prefix, wildcard := wildcardPrefix(name); {
+	case wildcard && prefix == "":
+		desc = "every channel"
+	case wildcard:
+		desc = fmt.Sprintf("channels in the namespace %q", strings.TrimSuffix(prefix, ":"))
+	default:
+		desc = fmt.Sprintf("the channel %q", name)
+	}
+	switch qualifier {
+	case "":
+	case "*":
+		desc += ", including qualified channels"
+	default:
+		desc += fmt.Sprintf(", qualified with [%s]", qualifier)
+	}
+	return desc
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
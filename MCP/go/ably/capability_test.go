@@ -0,0 +1,239 @@
+package ably
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCapabilityValidate(t *testing.T) {
+	tests := []struct {
+		resource string
+		valid    bool
+	}{
+		{"*", true},
+		{"chat", true},
+		{"chat:*", true},
+		{"chat:room:*", true},
+		{"[*]*", true},
+		{"[*]chat:*", true},
+		{"[*]chat", true},
+		{"[meta]*", true},
+		{"[meta]log", true},
+		{"fo*", false},
+		{"*foo", false},
+		{"chat:*:room", false},
+		{":*", false},
+		{"**", false},
+		{"[*]fo*", false},
+		{"[meta", false},
+		{"[]chat", false},
+		{"[m*]chat", false},
+		{"[meta]", false},
+	}
+	for _, tt := range tests {
+		err := Capability{tt.resource: {"publish"}}.Validate()
+		if (err == nil) != tt.valid {
+			t.Errorf("Validate(%q) = %v, want valid %v", tt.resource, err, tt.valid)
+		}
+	}
+}
+
+func TestCapabilityAllows(t *testing.T) {
+	tests := []struct {
+		resource, channel string
+		want              bool
+	}{
+		{"*", "chat", true},
+		{"*", "chat:room", true},
+		{"*", "[meta]log", false},
+		{"chat:*", "chat:room", true},
+		{"chat:*", "chat:room:1", true},
+		{"chat:*", "chat", false},
+		{"chat:*", "chatter:room", false},
+		{"chat", "chat", true},
+		{"chat", "chat:room", false},
+		{"[*]*", "[meta]log", true},
+		{"[*]*", "chat", true},
+		{"[*]chat:*", "[meta]chat:room", true},
+		{"[*]chat", "[meta]chat", true},
+		{"[meta]*", "[meta]log", true},
+		{"[meta]*", "log", false},
+		{"[meta]*", "[other]log", false},
+		{"[meta]log", "[meta]log", true},
+	}
+	for _, tt := range tests {
+		if got := (Capability{tt.resource: {"subscribe"}}).Allows(tt.channel, "subscribe"); got != tt.want {
+			t.Errorf("%q allows %q = %v, want %v", tt.resource, tt.channel, got, tt.want)
+		}
+	}
+}
+
+func TestCapabilityIsSubsetOf(t *testing.T) {
+	tests := []struct {
+		name      string
+		c, other  Capability
+		want      bool
+		exceeding Capability
+	}{
+		{
+			name:      "same",
+			c:         Capability{"chat": {"publish"}},
+			other:     Capability{"chat": {"publish"}},
+			want:      true,
+			exceeding: Capability{},
+		},
+		{
+			name:      "namespace within everything",
+			c:         Capability{"chat:*": {"publish", "subscribe"}},
+			other:     Capability{"*": {"*"}},
+			want:      true,
+			exceeding: Capability{},
+		},
+		{
+			name:      "sub-namespace within namespace",
+			c:         Capability{"chat:room:*": {"subscribe"}},
+			other:     Capability{"chat:*": {"subscribe"}},
+			want:      true,
+			exceeding: Capability{},
+		},
+		{
+			name:      "everything beyond a namespace",
+			c:         Capability{"*": {"subscribe"}},
+			other:     Capability{"chat:*": {"subscribe"}},
+			want:      false,
+			exceeding: Capability{"*": {"subscribe"}},
+		},
+		{
+			name:      "extra operation",
+			c:         Capability{"chat": {"history", "publish"}},
+			other:     Capability{"chat": {"publish"}},
+			want:      false,
+			exceeding: Capability{"chat": {"history"}},
+		},
+		{
+			name:      "not a wildcard",
+			c:         Capability{"foo": {"publish"}},
+			other:     Capability{"fo*": {"publish"}},
+			want:      false,
+			exceeding: Capability{"foo": {"publish"}},
+		},
+		{
+			name:      "qualified beyond unqualified",
+			c:         Capability{"[meta]log": {"subscribe"}},
+			other:     Capability{"*": {"*"}},
+			want:      false,
+			exceeding: Capability{"[meta]log": {"subscribe"}},
+		},
+		{
+			name:      "qualified within any qualifier",
+			c:         Capability{"[meta]log": {"subscribe"}, "chat": {"publish"}},
+			other:     Capability{"[*]*": {"*"}},
+			want:      true,
+			exceeding: Capability{},
+		},
+		{
+			name:      "any qualifier beyond one",
+			c:         Capability{"[*]*": {"subscribe"}},
+			other:     Capability{"[meta]*": {"subscribe"}},
+			want:      false,
+			exceeding: Capability{"[*]*": {"subscribe"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.IsSubsetOf(tt.other); got != tt.want {
+				t.Errorf("IsSubsetOf = %v, want %v", got, tt.want)
+			}
+			if got := tt.c.Exceeding(tt.other); !reflect.DeepEqual(got, tt.exceeding) {
+				t.Errorf("Exceeding = %v, want %v", got, tt.exceeding)
+			}
+		})
+	}
+}
+
+func TestCapabilityIntersect(t *testing.T) {
+	tests := []struct {
+		name     string
+		c, other Capability
+		want     Capability
+	}{
+		{
+			name:  "narrower pattern wins",
+			c:     Capability{"*": {"subscribe", "publish"}},
+			other: Capability{"chat:*": {"*"}},
+			want:  Capability{"chat:*": {"publish", "subscribe"}},
+		},
+		{
+			name:  "common operations",
+			c:     Capability{"chat": {"history", "publish"}},
+			other: Capability{"chat": {"publish", "subscribe"}},
+			want:  Capability{"chat": {"publish"}},
+		},
+		{
+			name:  "disjoint channels",
+			c:     Capability{"chat:*": {"publish"}},
+			other: Capability{"news:*": {"publish"}},
+			want:  Capability{},
+		},
+		{
+			name:  "disjoint operations",
+			c:     Capability{"chat": {"publish"}},
+			other: Capability{"*": {"subscribe"}},
+			want:  Capability{},
+		},
+		{
+			name:  "not a wildcard",
+			c:     Capability{"foo": {"publish"}},
+			other: Capability{"fo*": {"publish"}},
+			want:  Capability{},
+		},
+		{
+			name:  "qualified within any qualifier",
+			c:     Capability{"[meta]*": {"subscribe"}},
+			other: Capability{"[*]*": {"*"}},
+			want:  Capability{"[meta]*": {"subscribe"}},
+		},
+		{
+			name:  "qualified outside unqualified",
+			c:     Capability{"[meta]*": {"subscribe"}},
+			other: Capability{"*": {"*"}},
+			want:  Capability{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.Intersect(tt.other); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Intersect = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCapabilityOperations(t *testing.T) {
+	current := `{"chat:*":["object-subscribe","object-publish","annotation-publish","annotation-subscribe","message-update-own","message-update-any","message-delete-own","message-delete-any"]}`
+	if _, err := ParseCapability(current); err != nil {
+		t.Errorf("ParseCapability of current operations: %v", err)
+	}
+
+	// User input is checked against the known operations, a key's
+	// capability is taken as Ably reports it
+	future := `{"chat:*":["publish","future-op"]}`
+	if _, err := ParseCapability(future); err == nil || !strings.Contains(err.Error(), "unknown operation") {
+		t.Errorf("ParseCapability of an unknown operation error = %v", err)
+	}
+	key, err := ParseKeyCapability(future)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Capability{"chat:*": {"future-op", "publish"}}); !reflect.DeepEqual(key, want) {
+		t.Errorf("ParseKeyCapability = %v, want %v", key, want)
+	}
+	requested := Capability{"chat:room": {"future-op", "subscribe"}}
+	if got, want := requested.Intersect(key), (Capability{"chat:room": {"future-op"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Intersect = %v, want %v", got, want)
+	}
+	if _, err := ParseKeyCapability(`{"chat":[""]}`); err == nil {
+		t.Error("ParseKeyCapability accepted an empty operation")
+	}
+}
@@ -0,0 +1,103 @@
+package ably
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// CipherKey returns the AES key configured for channel, or nil when the
+// channel is not encrypted.
+func (c *Client) CipherKey(channel string) []byte {
+	return c.cfg.CipherKeys[channel]
+}
+
+// cipherStep returns the encoding step of payloads encrypted with key,
+// e.g. cipher+aes-128-cbc.
+func cipherStep(key []byte) string {
+	return fmt.Sprintf("cipher+aes-%d-cbc", len(key)*8)
+}
+
+// EncryptData encrypts a payload already prepared by EncodeData, as Ably
+// clients do on encrypted channels: strings are encrypted as UTF-8, and
+// the random IV is prepended to the ciphertext, which is base64 encoded.
+func EncryptData(data interface{}, encoding string, key []byte) (interface{}, string, error) {
+	var plaintext []byte
+	switch v := data.(type) {
+	case nil:
+		return nil, encoding, nil
+	case string:
+		if lastStep(encoding) == EncodingBase64 {
+			b, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return nil, "", fmt.Errorf("data is not valid base64: %w", err)
+			}
+			plaintext = b
+			encoding = strings.TrimSuffix(strings.TrimSuffix(encoding, EncodingBase64), "/")
+		} else {
+			plaintext = []byte(v)
+			encoding = appendStep(encoding, EncodingUTF8)
+		}
+	default:
+		return nil, "", fmt.Errorf("cannot encrypt data of type %T", data)
+	}
+
+	ciphertext, err := Encrypt(key, plaintext)
+	if err != nil {
+		return nil, "", err
+	}
+	encoding = appendStep(appendStep(encoding, cipherStep(key)), EncodingBase64)
+	return base64.StdEncoding.EncodeToString(ciphertext), encoding, nil
+}
+
+// Encrypt encrypts plaintext with AES-CBC and PKCS#7 padding under a
+// random IV, which is returned in front of the ciphertext.
+func Encrypt(key, plaintext []byte) ([]byte, error) {
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	return encrypt(key, iv, plaintext)
+}
+
+func encrypt(key, iv, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	padded := append(bytes.Clone(plaintext), bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	out := make([]byte, aes.BlockSize+len(padded))
+	copy(out, iv)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out[aes.BlockSize:], padded)
+	return out, nil
+}
+
+// Decrypt reverses Encrypt.
+func Decrypt(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2*aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("ciphertext has invalid length %d", len(data))
+	}
+	plaintext := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, data[:aes.BlockSize]).CryptBlocks(plaintext, data[aes.BlockSize:])
+
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	for _, b := range plaintext[len(plaintext)-padding:] {
+		if int(b) != padding {
+			return nil, fmt.Errorf("invalid padding")
+		}
+	}
+	return plaintext[:len(plaintext)-padding], nil
+}
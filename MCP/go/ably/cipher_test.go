@@ -0,0 +1,176 @@
+package ably
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/platform-api/mcp-server/models"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// The string fixture of crypto-data-128.json in Ably's shared client test
+// resources (ably-common), which every Ably client library decrypts.
+const (
+	vectorKey        = "WUP6u0K7MXI5Zeo0VppPwg=="
+	vectorIV         = "HO4cYSP8LybPYBPZPHQOtg=="
+	vectorPlaintext  = "The quick brown fox jumped over the lazy dog"
+	vectorCiphertext = "HO4cYSP8LybPYBPZPHQOtmHItcxYdSvcNUC6kXVpMn0VFL+9z2/5tJ6WFbR0SBT1xhFRuJ+MeBGTU3yOY9P5ow=="
+	vectorEncoding   = "utf-8/cipher+aes-128-cbc/base64"
+)
+
+func decodeBase64(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestEncryptVector(t *testing.T) {
+	key, iv := decodeBase64(t, vectorKey), decodeBase64(t, vectorIV)
+	ciphertext, err := encrypt(key, iv, []byte(vectorPlaintext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := base64.StdEncoding.EncodeToString(ciphertext); got != vectorCiphertext {
+		t.Errorf("ciphertext = %s, want %s", got, vectorCiphertext)
+	}
+}
+
+func TestDecryptVector(t *testing.T) {
+	plaintext, err := Decrypt(decodeBase64(t, vectorKey), decodeBase64(t, vectorCiphertext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(plaintext) != vectorPlaintext {
+		t.Errorf("plaintext = %q, want %q", plaintext, vectorPlaintext)
+	}
+}
+
+func TestDecodeDataVector(t *testing.T) {
+	data, encoding := DecodeData(vectorCiphertext, vectorEncoding, decodeBase64(t, vectorKey))
+	if data != vectorPlaintext || encoding != "" {
+		t.Errorf("decoded to %#v with encoding %q, want %q fully decoded", data, encoding, vectorPlaintext)
+	}
+}
+
+func TestEncryptDataRoundTrip(t *testing.T) {
+	key := decodeBase64(t, vectorKey)
+	data, encoding, err := EncryptData(vectorPlaintext, "", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encoding != vectorEncoding {
+		t.Errorf("encoding = %q, want %q", encoding, vectorEncoding)
+	}
+	// A random IV makes every encryption different
+	if data == vectorCiphertext {
+		t.Error("encryption reused the IV of the test vector")
+	}
+	decoded, rest := DecodeData(data, encoding, key)
+	if decoded != vectorPlaintext || rest != "" {
+		t.Errorf("round trip gave %#v with encoding %q", decoded, rest)
+	}
+}
+
+func TestEncryptDataBinary(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	payload := []byte{0, 1, 2, 0xff, 0xfe}
+	data, encoding, err := EncodeData(payload, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, encoding, err = EncryptData(data, encoding, key); err != nil {
+		t.Fatal(err)
+	}
+	if encoding != "cipher+aes-256-cbc/base64" {
+		t.Errorf("encoding = %q, want cipher+aes-256-cbc/base64", encoding)
+	}
+	decoded, rest := DecodeData(data, encoding, key)
+	if want := base64.StdEncoding.EncodeToString(payload); decoded != want || rest != EncodingBase64 {
+		t.Errorf("decoded to %#v with encoding %q, want %q with base64", decoded, rest, want)
+	}
+}
+
+func TestEncryptJSONChain(t *testing.T) {
+	key := decodeBase64(t, vectorKey)
+	m := &models.Message{Data: map[string]any{"count": 3.0, "tags": []any{"a", "b"}}}
+	if err := EncodeMessage(m, key); err != nil {
+		t.Fatal(err)
+	}
+	if want := "json/utf-8/cipher+aes-128-cbc/base64"; m.Encoding != want {
+		t.Fatalf("encoding = %q, want %q", m.Encoding, want)
+	}
+	DecodeMessage(m, key)
+	data, ok := m.Data.(map[string]any)
+	if !ok || data["count"] != 3.0 || len(data["tags"].([]any)) != 2 || m.Encoding != "" {
+		t.Errorf("decoded to %#v with encoding %q", m.Data, m.Encoding)
+	}
+}
+
+func TestDecodeMsgpackEncrypted(t *testing.T) {
+	// Over msgpack Ably sends the ciphertext as binary, without the final
+	// base64 step
+	body, err := msgpack.Marshal(map[string]any{
+		"name":     "example",
+		"data":     decodeBase64(t, vectorCiphertext),
+		"encoding": "utf-8/cipher+aes-128-cbc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m models.Message
+	header := http.Header{"Content-Type": {contentTypeMsgpack}}
+	if err := decodeBody(header, body, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Data != vectorCiphertext || m.Encoding != vectorEncoding {
+		t.Errorf("msgpack body decoded to %#v with encoding %q", m.Data, m.Encoding)
+	}
+	DecodeMessage(&m, decodeBase64(t, vectorKey))
+	if m.Data != vectorPlaintext || m.Encoding != "" {
+		t.Errorf("decrypted to %#v with encoding %q", m.Data, m.Encoding)
+	}
+}
+
+func TestEncodeMsgpackEncrypted(t *testing.T) {
+	m := &models.Message{Data: vectorCiphertext, Encoding: vectorEncoding}
+	body, contentType, err := encodeBody("msgpack", m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != contentTypeMsgpack {
+		t.Fatalf("content type = %s, want %s", contentType, contentTypeMsgpack)
+	}
+	var sent map[string]any
+	if err := msgpack.Unmarshal(body, &sent); err != nil {
+		t.Fatal(err)
+	}
+	if b, ok := sent["data"].([]byte); !ok || base64.StdEncoding.EncodeToString(b) != vectorCiphertext {
+		t.Errorf("data sent as %#v, want the ciphertext as binary", sent["data"])
+	}
+	if sent["encoding"] != "utf-8/cipher+aes-128-cbc" {
+		t.Errorf("encoding sent as %#v, want utf-8/cipher+aes-128-cbc", sent["encoding"])
+	}
+}
+
+func TestDecodeDataWrongKey(t *testing.T) {
+	wrong := bytes.Repeat([]byte{1}, 16)
+	data, encoding := DecodeData(vectorCiphertext, vectorEncoding, wrong)
+	if data != vectorCiphertext || encoding != vectorEncoding {
+		t.Errorf("decoded with the wrong key to %#v with encoding %q, want it left encrypted", data, encoding)
+	}
+	if _, err := Decrypt(wrong, decodeBase64(t, vectorCiphertext)); err == nil {
+		t.Error("Decrypt with the wrong key succeeded")
+	}
+}
+
+func TestDecodeDataWithoutKey(t *testing.T) {
+	data, encoding := DecodeData(vectorCiphertext, vectorEncoding, nil)
+	if data != vectorCiphertext || encoding != vectorEncoding {
+		t.Errorf("decoded without a key to %#v with encoding %q, want it left encrypted", data, encoding)
+	}
+}
@@ -0,0 +1,172 @@
+// Package ably implements the Ably REST client shared by every MCP tool. It
+// owns request construction, authentication, response decoding and error
+// mapping so that individual tool handlers only deal with their arguments.
+package ably
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/platform-api/mcp-server/config"
+)
+
+// Client sends requests to the Ably REST API described by an APIConfig.
+type Client struct {
+	cfg        *config.APIConfig
+	httpClient *http.Client
+}
+
+// Request describes a single call to the Ably REST API.
+type Request struct {
+	Method string
+	Path   string      // Path relative to the configured base URL, see Path.
+	Query  url.Values  // Optional query string parameters.
+	Body   interface{} // Optional request body, encoded in the configured wire format.
+
+	// Idempotent marks a POST or PATCH as safe to retry, e.g. a publish
+	// whose messages all carry an ID. Other methods are always retried.
+	Idempotent bool
+
+	auth authMode
+}
+
+// Response holds the raw result of a successful Ably REST call.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// NewClient returns a Client for the given configuration.
+func NewClient(cfg *config.APIConfig) *Client {
+	return &Client{
+		cfg:        cfg,
+		httpClient: http.DefaultClient,
+	}
+}
+
+// Do sends r and returns the response. Transport failures and HTTP status
+// codes >= 400 are reported as errors; the latter as *Error. Idempotent
+// requests are retried as described in sendWithRetry. A request rejected
+// because a token minted from the API key has expired or been revoked is
+// repeated once with a freshly minted token.
+func (c *Client) Do(ctx context.Context, r *Request) (*Response, error) {
+	resp, err := c.sendWithRetry(ctx, r)
+	var apiErr *Error
+	if c.usesMintedToken(r) && errors.As(err, &apiErr) && apiErr.isTokenError() {
+		c.invalidateToken()
+		resp, err = c.sendWithRetry(ctx, r)
+	}
+	return resp, err
+}
+
+// send makes a single attempt at r against baseURL, in the configured wire
+// format. If Ably refuses msgpack, the attempt is repeated in JSON.
+func (c *Client) send(ctx context.Context, r *Request, baseURL string) (*Response, error) {
+	if c.cfg.Format != config.FormatMsgpack {
+		return c.sendFormat(ctx, r, baseURL, config.FormatJSON)
+	}
+	resp, err := c.sendFormat(ctx, r, baseURL, config.FormatMsgpack)
+	var apiErr *Error
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotAcceptable || apiErr.StatusCode == http.StatusUnsupportedMediaType) {
+		return c.sendFormat(ctx, r, baseURL, config.FormatJSON)
+	}
+	return resp, err
+}
+
+func (c *Client) sendFormat(ctx context.Context, r *Request, baseURL, format string) (*Response, error) {
+	var body io.Reader
+	var contentType string
+	if r.Body != nil {
+		bodyBytes, bodyType, err := encodeBody(format, r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		body, contentType = bytes.NewReader(bodyBytes), bodyType
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.Method, requestURL(baseURL, r), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if format == config.FormatMsgpack {
+		// Ably may still answer in JSON, which decodeBody handles too
+		req.Header.Set("Accept", contentTypeMsgpack+", "+contentTypeJSON)
+	} else {
+		req.Header.Set("Accept", contentTypeJSON)
+	}
+	if c.cfg.APIVersion != "" {
+		req.Header.Set("X-Ably-Version", c.cfg.APIVersion)
+	}
+	if err := c.authorize(ctx, r, req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, &networkError{err: err}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &networkError{err: fmt.Errorf("failed to read response body: %w", err)}
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, newError(resp.StatusCode, resp.Header, respBody)
+	}
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       respBody,
+	}, nil
+}
+
+// Decode unmarshals the response body into v, whichever wire format it
+// is in.
+func (r *Response) Decode(v interface{}) error {
+	return decodeBody(r.Header, r.Body, v)
+}
+
+func requestURL(baseURL string, r *Request) string {
+	u := strings.TrimRight(baseURL, "/") + r.Path
+	if len(r.Query) > 0 {
+		u += "?" + r.Query.Encode()
+	}
+	return u
+}
+
+// Path joins segments into a request path, escaping each one so that
+// channel names and device IDs containing reserved characters stay intact.
+func Path(segments ...string) string {
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteByte('/')
+		b.WriteString(url.PathEscape(s))
+	}
+	return b.String()
+}
+
+// FormatParam renders a tool argument as a query string value. Numbers
+// arrive from MCP clients as float64 and are written without an exponent.
+func FormatParam(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,98 @@
+package ably
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// clockSamples is how many times MeasureClock asks Ably for the time.
+const clockSamples = 3
+
+// clockOffsets holds the offset of Ably's clock from the local one that Now
+// corrects the local time by, keyed by base URL. Like the token cache it is
+// shared by all clients: it describes the local clock against an endpoint,
+// not a session, but sessions using other endpoints must not change it.
+var clockOffsets = struct {
+	sync.RWMutex
+	offsets map[string]time.Duration
+}{offsets: make(map[string]time.Duration)}
+
+// SetClockOffset sets the offset Now corrects the local time by for the
+// endpoint at baseURL.
+func SetClockOffset(baseURL string, offset time.Duration) {
+	clockOffsets.Lock()
+	defer clockOffsets.Unlock()
+	clockOffsets.offsets[baseURL] = offset
+}
+
+// ClockOffset returns the offset Now corrects the local time by for the
+// endpoint at baseURL, zero unless SetClockOffset was called for it.
+func ClockOffset(baseURL string) time.Duration {
+	clockOffsets.RLock()
+	defer clockOffsets.RUnlock()
+	return clockOffsets.offsets[baseURL]
+}
+
+// Now returns the current time by the clock of the endpoint at baseURL, as
+// estimated from the local clock and the offset set with SetClockOffset. It
+// is used wherever a time is sent to Ably, such as TokenRequest timestamps
+// and time range bounds.
+func Now(baseURL string) time.Time {
+	return time.Now().Add(ClockOffset(baseURL))
+}
+
+// Now returns the current time by the clock of the endpoint of c.
+func (c *Client) Now() time.Time {
+	return Now(c.cfg.BaseURL)
+}
+
+// SetClockOffset sets the offset Now corrects the local time by for the
+// endpoint of c.
+func (c *Client) SetClockOffset(offset time.Duration) {
+	SetClockOffset(c.cfg.BaseURL, offset)
+}
+
+// ClockSample is a measurement of the local clock against Ably's.
+type ClockSample struct {
+	ServerTime time.Time     // Ably's time when it answered.
+	Offset     time.Duration // Added to the local time, gives Ably's time.
+	RoundTrip  time.Duration // Duration of the request for the time.
+}
+
+// MeasureClock estimates the offset of Ably's clock from the local one by
+// asking Ably for the time. Ably's time is taken to be that of the middle of
+// the round trip, so the sample with the shortest round trip is returned as
+// the most accurate. The time needs no credentials and none are sent, as
+// tokens minted from an API key are rejected when the clocks disagree.
+func (c *Client) MeasureClock(ctx context.Context) (*ClockSample, error) {
+	var best *ClockSample
+	for range clockSamples {
+		sent := time.Now()
+		resp, err := c.Do(ctx, &Request{
+			Method: http.MethodGet,
+			Path:   Path("time"),
+			auth:   authNone,
+		})
+		if err != nil {
+			return nil, err
+		}
+		received := time.Now()
+		var times []int64
+		if err := resp.Decode(&times); err != nil || len(times) == 0 {
+			return nil, fmt.Errorf("unexpected time response: %s", resp.Body)
+		}
+
+		sample := &ClockSample{
+			ServerTime: time.UnixMilli(times[0]),
+			RoundTrip:  received.Sub(sent),
+		}
+		sample.Offset = sample.ServerTime.Sub(sent.Add(sample.RoundTrip / 2))
+		if best == nil || sample.RoundTrip < best.RoundTrip {
+			best = sample
+		}
+	}
+	return best, nil
+}
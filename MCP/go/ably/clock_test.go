@@ -0,0 +1,22 @@
+package ably
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockOffsetPerEndpoint(t *testing.T) {
+	const a, b = "https://a.example.test", "https://b.example.test"
+	SetClockOffset(a, time.Hour)
+	defer SetClockOffset(a, 0)
+
+	if got := ClockOffset(a); got != time.Hour {
+		t.Errorf("offset of %s = %v, want 1h", a, got)
+	}
+	if got := ClockOffset(b); got != 0 {
+		t.Errorf("offset of %s = %v, want 0", b, got)
+	}
+	if d := Now(a).Sub(time.Now()); d < 59*time.Minute {
+		t.Errorf("Now(%s) is %v ahead, want about 1h", a, d)
+	}
+}
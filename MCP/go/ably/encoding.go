@@ -0,0 +1,150 @@
+package ably
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/platform-api/mcp-server/models"
+)
+
+// Steps of a payload encoding chain. The encoding of a payload lists the
+// steps applied to it, separated by "/", in the order they were applied.
+const (
+	EncodingJSON   = "json"
+	EncodingBase64 = "base64"
+	EncodingUTF8   = "utf-8"
+)
+
+// EncodeData prepares a payload for publishing. Strings are sent as they
+// are, and must be valid base64 when the encoding ends in base64. Binary
+// data is base64 encoded, and any other value is sent as a JSON string.
+// The encoding of the returned payload is returned with it.
+func EncodeData(data interface{}, encoding string) (interface{}, string, error) {
+	switch v := data.(type) {
+	case nil:
+		return nil, encoding, nil
+	case string:
+		if lastStep(encoding) == EncodingBase64 {
+			if _, err := base64.StdEncoding.DecodeString(v); err != nil {
+				return nil, "", fmt.Errorf("data is not valid base64: %w", err)
+			}
+		}
+		return v, encoding, nil
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v), appendStep(encoding, EncodingBase64), nil
+	default:
+		if encoding != "" && encoding != EncodingJSON {
+			return nil, "", fmt.Errorf("encoding %q requires data to be a string", encoding)
+		}
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, "", fmt.Errorf("data cannot be encoded as JSON: %w", err)
+		}
+		return string(encoded), EncodingJSON, nil
+	}
+}
+
+// DecodeData undoes the steps of an encoding chain, last step first, and
+// returns the payload with the steps it could not undo. Encrypted payloads
+// are decrypted when key is set. JSON payloads are returned as native
+// values. A payload left binary is returned base64 encoded, with base64 as
+// its remaining encoding.
+func DecodeData(data interface{}, encoding string, key []byte) (interface{}, string) {
+	steps := splitEncoding(encoding)
+	for len(steps) > 0 {
+		decoded, ok := decodeStep(data, steps[len(steps)-1], key)
+		if !ok {
+			break
+		}
+		data = decoded
+		steps = steps[:len(steps)-1]
+	}
+	if b, ok := data.([]byte); ok {
+		return base64.StdEncoding.EncodeToString(b), appendStep(strings.Join(steps, "/"), EncodingBase64)
+	}
+	return data, strings.Join(steps, "/")
+}
+
+func decodeStep(data interface{}, step string, key []byte) (interface{}, bool) {
+	switch step {
+	case cipherStep(key):
+		b, ok := data.([]byte)
+		if !ok || key == nil {
+			return nil, false
+		}
+		plaintext, err := Decrypt(key, b)
+		return plaintext, err == nil
+	case EncodingBase64:
+		s, ok := data.(string)
+		if !ok {
+			return nil, false
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		return b, err == nil
+	case EncodingUTF8:
+		b, ok := data.([]byte)
+		return string(b), ok
+	case EncodingJSON:
+		var raw []byte
+		switch v := data.(type) {
+		case string:
+			raw = []byte(v)
+		case []byte:
+			raw = v
+		default:
+			return nil, false
+		}
+		var value interface{}
+		err := json.Unmarshal(raw, &value)
+		return value, err == nil
+	}
+	return nil, false
+}
+
+// EncodeMessage prepares the payload of m for publishing, encrypting it
+// when key is set.
+func EncodeMessage(m *models.Message, key []byte) error {
+	data, encoding, err := EncodeData(m.Data, m.Encoding)
+	if err != nil {
+		return err
+	}
+	if key != nil {
+		if data, encoding, err = EncryptData(data, encoding, key); err != nil {
+			return err
+		}
+	}
+	m.Data, m.Encoding = data, encoding
+	return nil
+}
+
+// DecodeMessage decodes the payload of a message read from Ably,
+// decrypting it when key is set.
+func DecodeMessage(m *models.Message, key []byte) {
+	m.Data, m.Encoding = DecodeData(m.Data, m.Encoding, key)
+}
+
+// DecodePresenceMessage decodes the payload of a presence message read
+// from Ably, decrypting it when key is set.
+func DecodePresenceMessage(p *models.PresenceMessage, key []byte) {
+	p.Data, p.Encoding = DecodeData(p.Data, p.Encoding, key)
+}
+
+func splitEncoding(encoding string) []string {
+	if encoding == "" {
+		return nil
+	}
+	return strings.Split(encoding, "/")
+}
+
+func lastStep(encoding string) string {
+	return encoding[strings.LastIndex(encoding, "/")+1:]
+}
+
+func appendStep(encoding, step string) string {
+	if encoding == "" {
+		return step
+	}
+	return encoding + "/" + step
+}
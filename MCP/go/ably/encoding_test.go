@@ -0,0 +1,36 @@
+package ably
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeData(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         any
+		encoding     string
+		want         any
+		wantEncoding string
+	}{
+		{"plain string", "hello", "", "hello", ""},
+		{"json", `{"a":1}`, "json", map[string]any{"a": 1.0}, ""},
+		{"utf-8 base64", "aMOpbGxv", "utf-8/base64", "héllo", ""},
+		{"json utf-8 base64", "eyJhIjpbMSwyXX0=", "json/utf-8/base64", map[string]any{"a": []any{1.0, 2.0}}, ""},
+		{"binary", "AAEC", "base64", "AAEC", "base64"},
+		// Steps are undone last first, so an unknown step stops decoding
+		// and is returned with the steps before it
+		{"unknown step", "eyJhIjoxfQ==", "json/custom/base64", "eyJhIjoxfQ==", "json/custom/base64"},
+		{"unknown step after decoded ones", "aGk=", "custom/utf-8/base64", "hi", "custom"},
+		{"invalid base64", "not base64!", "base64", "not base64!", "base64"},
+		{"invalid json", "{", "json", "{", "json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, encoding := DecodeData(tt.data, tt.encoding, nil)
+			if !reflect.DeepEqual(data, tt.want) || encoding != tt.wantEncoding {
+				t.Errorf("DecodeData(%#v, %q) = %#v, %q; want %#v, %q", tt.data, tt.encoding, data, encoding, tt.want, tt.wantEncoding)
+			}
+		})
+	}
+}
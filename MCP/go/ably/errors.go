@@ -0,0 +1,127 @@
+package ably
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/platform-api/mcp-server/models"
+)
+
+// ErrorCategory groups Ably errors by how a caller should react to them.
+type ErrorCategory string
+
+const (
+	CategoryAuth       ErrorCategory = "auth"       // Credentials are missing, invalid or expired; re-authenticate.
+	CategoryRateLimit  ErrorCategory = "rate_limit" // Too many requests; retry after backing off.
+	CategoryNotFound   ErrorCategory = "not_found"  // The resource does not exist; do not retry.
+	CategoryValidation ErrorCategory = "validation" // The request is malformed; fix it before retrying.
+	CategoryServer     ErrorCategory = "server"     // Ably failed to handle the request; retrying may succeed.
+)
+
+// Error is returned by Client.Do when Ably answers with an HTTP status code
+// of 400 or above.
+type Error struct {
+	Info       models.Error // Decoded from the body and enriched from x-ably-* headers.
+	Category   ErrorCategory
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// errorBody is the envelope Ably wraps error responses in.
+type errorBody struct {
+	Error *models.Error `json:"error"`
+}
+
+func newError(statusCode int, header http.Header, body []byte) *Error {
+	e := &Error{StatusCode: statusCode, Header: header, Body: body}
+
+	var envelope errorBody
+	if err := decodeBody(header, body, &envelope); err == nil && envelope.Error != nil {
+		e.Info = *envelope.Error
+	}
+	if e.Info.Code == 0 {
+		e.Info.Code, _ = strconv.Atoi(header.Get("X-Ably-Errorcode"))
+	}
+	if e.Info.Message == "" {
+		e.Info.Message = header.Get("X-Ably-Errormessage")
+	}
+	if e.Info.Message == "" && !isMsgpack(header) {
+		e.Info.Message = strings.TrimSpace(string(body))
+	}
+	if e.Info.Message == "" {
+		e.Info.Message = http.StatusText(statusCode)
+	}
+	if e.Info.Serverid == "" {
+		e.Info.Serverid = header.Get("X-Ably-Serverid")
+	}
+	if e.Info.Statuscode == 0 {
+		e.Info.Statuscode = statusCode
+	}
+	if e.Info.Href == "" && e.Info.Code != 0 {
+		e.Info.Href = fmt.Sprintf("https://help.ably.io/error/%d", e.Info.Code)
+	}
+	e.Category = categorize(statusCode, e.Info.Code)
+	return e
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("API error: %s (HTTP %d, %s)", e.Info.Message, e.StatusCode, e.Category)
+	if e.Info.Code != 0 {
+		msg = fmt.Sprintf("API error: %s (code %d, HTTP %d, %s)", e.Info.Message, e.Info.Code, e.StatusCode, e.Category)
+	}
+	if e.Info.Href != "" {
+		msg += "; see " + e.Info.Href
+	}
+	return msg
+}
+
+// isTokenError reports whether the request was rejected because its token
+// expired or was revoked (Ably error codes 40140 to 40149).
+func (e *Error) isTokenError() bool {
+	if e.Info.Code == 0 {
+		return e.StatusCode == http.StatusUnauthorized
+	}
+	return e.Info.Code >= 40140 && e.Info.Code < 40150
+}
+
+// Retryable reports whether repeating the same request may succeed.
+func (e *Error) Retryable() bool {
+	return e.Category == CategoryServer || e.Category == CategoryRateLimit
+}
+
+// Action suggests what a caller should do next.
+func (e *Error) Action() string {
+	switch e.Category {
+	case CategoryAuth:
+		return "reauthenticate"
+	case CategoryRateLimit, CategoryServer:
+		return "retry"
+	case CategoryValidation:
+		return "fix_request"
+	default:
+		return "give_up"
+	}
+}
+
+// categorize derives the category from the Ably error code, whose first
+// three digits mirror an HTTP status, falling back to the response status.
+func categorize(statusCode, code int) ErrorCategory {
+	if code >= 10000 && code < 100000 {
+		statusCode = code / 100
+	}
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return CategoryAuth
+	case statusCode == http.StatusTooManyRequests:
+		return CategoryRateLimit
+	case statusCode == http.StatusNotFound:
+		return CategoryNotFound
+	case statusCode >= 500:
+		return CategoryServer
+	default:
+		return CategoryValidation
+	}
+}
@@ -0,0 +1,99 @@
+package ably
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/platform-api/mcp-server/models"
+)
+
+func TestNewErrorHeaders(t *testing.T) {
+	// Ably may answer without a body, leaving the details to headers
+	header := http.Header{
+		"X-Ably-Errorcode":    {"40160"},
+		"X-Ably-Errormessage": {"action not permitted"},
+		"X-Ably-Serverid":     {"frontend.abc123"},
+	}
+	e := newError(http.StatusUnauthorized, header, nil)
+	if e.Info.Code != 40160 || e.Info.Message != "action not permitted" || e.Info.Serverid != "frontend.abc123" {
+		t.Errorf("info = %+v", e.Info)
+	}
+	if e.Info.Statuscode != http.StatusUnauthorized || e.Info.Href != "https://help.ably.io/error/40160" {
+		t.Errorf("info = %+v", e.Info)
+	}
+	if msg := e.Error(); !strings.Contains(msg, "code 40160") || !strings.Contains(msg, "help.ably.io/error/40160") {
+		t.Errorf("Error() = %q", msg)
+	}
+
+	// The body wins over the headers
+	body := []byte(`{"error":{"code":40142,"statusCode":401,"message":"token expired","href":"https://help.ably.io/error/40142"}}`)
+	e = newError(http.StatusUnauthorized, header, body)
+	if e.Info.Code != 40142 || e.Info.Message != "token expired" || e.Info.Serverid != "frontend.abc123" {
+		t.Errorf("info = %+v", e.Info)
+	}
+
+	// A body that is not an Ably error becomes the message
+	e = newError(http.StatusBadGateway, http.Header{}, []byte("upstream unavailable\n"))
+	if e.Info.Code != 0 || e.Info.Message != "upstream unavailable" || e.Info.Href != "" {
+		t.Errorf("info = %+v", e.Info)
+	}
+	e = newError(http.StatusServiceUnavailable, http.Header{}, nil)
+	if e.Info.Message != "Service Unavailable" {
+		t.Errorf("message = %q", e.Info.Message)
+	}
+}
+
+func TestCategorize(t *testing.T) {
+	tests := []struct {
+		status, code int
+		want         ErrorCategory
+		retryable    bool
+		action       string
+	}{
+		{http.StatusUnauthorized, 0, CategoryAuth, false, "reauthenticate"},
+		{http.StatusForbidden, 0, CategoryAuth, false, "reauthenticate"},
+		{http.StatusTooManyRequests, 0, CategoryRateLimit, true, "retry"},
+		{http.StatusNotFound, 0, CategoryNotFound, false, "give_up"},
+		{http.StatusBadRequest, 0, CategoryValidation, false, "fix_request"},
+		{http.StatusServiceUnavailable, 0, CategoryServer, true, "retry"},
+		// The Ably code takes precedence over the HTTP status
+		{http.StatusBadRequest, 40140, CategoryAuth, false, "reauthenticate"},
+		{http.StatusBadRequest, 50000, CategoryServer, true, "retry"},
+		{http.StatusInternalServerError, 40400, CategoryNotFound, false, "give_up"},
+		{http.StatusBadRequest, 42910, CategoryRateLimit, true, "retry"},
+		{http.StatusUnauthorized, 40000, CategoryValidation, false, "fix_request"},
+		// Codes outside the five-digit range are ignored
+		{http.StatusServiceUnavailable, 123, CategoryServer, true, "retry"},
+	}
+	for _, tt := range tests {
+		e := &Error{StatusCode: tt.status, Category: categorize(tt.status, tt.code)}
+		if e.Category != tt.want || e.Retryable() != tt.retryable || e.Action() != tt.action {
+			t.Errorf("HTTP %d, code %d: %s, retryable %v, %s; want %s, %v, %s",
+				tt.status, tt.code, e.Category, e.Retryable(), e.Action(), tt.want, tt.retryable, tt.action)
+		}
+	}
+}
+
+func TestIsTokenError(t *testing.T) {
+	tests := []struct {
+		status, code int
+		want         bool
+	}{
+		{http.StatusUnauthorized, 40140, true},
+		{http.StatusUnauthorized, 40142, true},
+		{http.StatusUnauthorized, 40149, true},
+		{http.StatusUnauthorized, 40150, false},
+		{http.StatusUnauthorized, 40160, false},
+		{http.StatusUnauthorized, 40101, false},
+		// Without a code, any 401 may be an expired token
+		{http.StatusUnauthorized, 0, true},
+		{http.StatusForbidden, 0, false},
+	}
+	for _, tt := range tests {
+		e := &Error{StatusCode: tt.status, Info: models.Error{Code: tt.code}}
+		if got := e.isTokenError(); got != tt.want {
+			t.Errorf("HTTP %d, code %d: isTokenError = %v, want %v", tt.status, tt.code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package ably
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/platform-api/mcp-server/models"
+)
+
+// MaxMessageSize is Ably's default limit, in bytes, on the size of a single
+// publish, summed over all messages it carries.
+const MaxMessageSize = 64 * 1024
+
+// MessageSize returns the size of m as Ably accounts for it: the lengths of
+// its name, client ID and data plus the JSON encoding of its extras. Binary
+// data counts with its decoded length, and JSON data with its encoded one.
+func MessageSize(m models.Message) int {
+	size := len(m.Name) + len(m.Clientid) + dataSize(m.Data, m.Encoding)
+	if extras, err := json.Marshal(m.Extras); err == nil && string(extras) != "{}" {
+		size += len(extras)
+	}
+	return size
+}
+
+func dataSize(data interface{}, encoding string) int {
+	switch v := data.(type) {
+	case nil:
+		return 0
+	case string:
+		if lastStep(encoding) == EncodingBase64 {
+			if b, err := base64.StdEncoding.DecodeString(v); err == nil {
+				return len(b)
+			}
+		}
+		return len(v)
+	case []byte:
+		return len(v)
+	default:
+		encoded, _ := json.Marshal(v)
+		return len(encoded)
+	}
+}
+
+// ValidateBatch checks that messages can be published in one request.
+func ValidateBatch(messages []models.Message) error {
+	if len(messages) == 0 {
+		return fmt.Errorf("no messages to publish")
+	}
+	total := 0
+	for _, m := range messages {
+		total += MessageSize(m)
+	}
+	if total > MaxMessageSize {
+		return fmt.Errorf("messages total %d bytes, exceeding the %d byte publish limit", total, MaxMessageSize)
+	}
+	return nil
+}
+
+// HasIDs reports whether every message carries a publisher-supplied ID,
+// which makes publishing them idempotent.
+func HasIDs(messages []models.Message) bool {
+	for _, m := range messages {
+		if m.Id == "" {
+			return false
+		}
+	}
+	return len(messages) > 0
+}
+
+// AssignIDs gives messages Ably-style idempotent IDs: a random base ID
+// shared by the batch, followed by ":" and the index of each message.
+// Publishing them is then idempotent, so a retry after a timeout cannot
+// publish the messages twice.
+func AssignIDs(messages []models.Message) error {
+	for _, m := range messages {
+		if m.Id != "" {
+			return fmt.Errorf("message IDs cannot be generated for messages that already have one")
+		}
+	}
+	b := make([]byte, 9)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Errorf("failed to generate message IDs: %w", err)
+	}
+	baseID := base64.StdEncoding.EncodeToString(b)
+	for i := range messages {
+		messages[i].Id = fmt.Sprintf("%s:%d", baseID, i)
+	}
+	return nil
+}
+
+// MessageIDs returns the IDs of published messages. Messages without an
+// ID of their own are identified by the publish's base ID and their index.
+func MessageIDs(messages []models.Message, baseID string) []string {
+	ids := make([]string, len(messages))
+	for i, m := range messages {
+		switch {
+		case m.Id != "":
+			ids[i] = m.Id
+		case baseID != "":
+			ids[i] = fmt.Sprintf("%s:%d", baseID, i)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,136 @@
+package ably
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MaxPages bounds how many pages a single tool call may fetch.
+const MaxPages = 100
+
+// MaxPageSize is the largest limit Ably accepts on a paginated endpoint,
+// for callers that page through as much as they can.
+const MaxPageSize = 1000
+
+// ParseLinks parses an RFC 5988 Link header, as sent by Ably on paginated
+// endpoints, into a map from relation ("first", "current", "next") to URL.
+func ParseLinks(header string) map[string]string {
+	links := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(part, ";")
+		if !ok {
+			continue
+		}
+		target = strings.TrimSpace(target)
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || !strings.EqualFold(name, "rel") {
+				continue
+			}
+			for _, rel := range strings.Fields(strings.Trim(value, `"`)) {
+				links[rel] = target[1 : len(target)-1]
+			}
+		}
+	}
+	return links
+}
+
+// NextCursor returns an opaque cursor for the page after r, or "" when r is
+// the last page.
+func (r *Response) NextCursor() string {
+	next, ok := ParseLinks(r.Header.Get("Link"))["next"]
+	if !ok {
+		return ""
+	}
+	_, query, _ := strings.Cut(next, "?")
+	if query == "" {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(query))
+}
+
+// ApplyCursor replaces the query of r with the one encoded in cursor, which
+// must come from a response to the same endpoint.
+func (r *Request) ApplyCursor(cursor string) error {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return fmt.Errorf("invalid cursor: %w", err)
+	}
+	query, err := url.ParseQuery(string(raw))
+	if err != nil {
+		return fmt.Errorf("invalid cursor: %w", err)
+	}
+	r.Query = query
+	return nil
+}
+
+// Paginate sends r, starting from cursor when it is set, and follows next
+// links until maxPages pages have been read or there are no more pages. It
+// returns the items of all pages read and the cursor of the following page.
+func Paginate[T any](ctx context.Context, c *Client, r *Request, cursor string, maxPages int) ([]T, string, error) {
+	if cursor != "" {
+		if err := r.ApplyCursor(cursor); err != nil {
+			return nil, "", err
+		}
+	}
+	var items []T
+	for page := 0; page < maxPages; page++ {
+		resp, err := c.Do(ctx, r)
+		if err != nil {
+			return nil, "", err
+		}
+		var pageItems []T
+		if err := resp.Decode(&pageItems); err != nil {
+			return nil, "", fmt.Errorf("failed to decode page %d: %w", page+1, err)
+		}
+		items = append(items, pageItems...)
+
+		cursor = resp.NextCursor()
+		if cursor == "" {
+			break
+		}
+		if err := r.ApplyCursor(cursor); err != nil {
+			return nil, "", err
+		}
+	}
+	return items, cursor, nil
+}
+
+// PaginationArgs reads the cursor and max_pages tool arguments. max_pages
+// defaults to a single page and is capped at MaxPages.
+func PaginationArgs(args map[string]any) (cursor string, maxPages int, err error) {
+	if val, ok := args["cursor"]; ok {
+		if cursor, ok = val.(string); !ok {
+			return "", 0, fmt.Errorf("invalid parameter: cursor must be a string")
+		}
+	}
+	maxPages = 1
+	if val, ok := args["max_pages"]; ok {
+		n, ok := val.(float64)
+		if !ok || n < 1 || n != float64(int(n)) {
+			return "", 0, fmt.Errorf("invalid parameter: max_pages must be a positive integer")
+		}
+		maxPages = min(int(n), MaxPages)
+	}
+	return cursor, maxPages, nil
+}
+
+// CountArg reads a positive integer argument, defaulting to def and capped
+// at limit.
+func CountArg(args map[string]any, name string, def, limit int) (int, error) {
+	val, ok := args[name]
+	if !ok {
+		return min(def, limit), nil
+	}
+	n, ok := val.(float64)
+	if !ok || n < 1 || n != float64(int(n)) {
+		return 0, fmt.Errorf("invalid parameter: %s must be a positive integer", name)
+	}
+	return min(int(n), limit), nil
+}
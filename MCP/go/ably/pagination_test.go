@@ -0,0 +1,145 @@
+package ably
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/platform-api/mcp-server/config"
+)
+
+// ablyLinks is a Link header as Ably sends it on a history page.
+const ablyLinks = `<./messages?start=0&end=1714566600000&limit=2&direction=backwards&format=json>; rel="first", ` +
+	`<./messages?start=0&end=1714566600000&limit=2&direction=backwards&format=json>; rel="current", ` +
+	`<./messages?start=0&end=1714566599000&limit=2&direction=backwards&format=json&serial=abc%3A1>; rel="next"`
+
+func TestParseLinks(t *testing.T) {
+	links := ParseLinks(ablyLinks)
+	want := map[string]string{
+		"first":   "./messages?start=0&end=1714566600000&limit=2&direction=backwards&format=json",
+		"current": "./messages?start=0&end=1714566600000&limit=2&direction=backwards&format=json",
+		"next":    "./messages?start=0&end=1714566599000&limit=2&direction=backwards&format=json&serial=abc%3A1",
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("ParseLinks = %v, want %v", links, want)
+	}
+
+	tests := []struct {
+		header string
+		want   map[string]string
+	}{
+		{"", map[string]string{}},
+		// Several relations in one parameter, extra parameters and unquoted values
+		{`<./stats?unit=hour>; title="x"; rel="first current"`, map[string]string{"first": "./stats?unit=hour", "current": "./stats?unit=hour"}},
+		{`<./stats?unit=hour>; REL=next`, map[string]string{"next": "./stats?unit=hour"}},
+		// Malformed parts are skipped
+		{`./stats; rel="next", <./stats?a=1>`, map[string]string{}},
+	}
+	for _, tt := range tests {
+		if got := ParseLinks(tt.header); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseLinks(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	resp := &Response{Header: http.Header{"Link": {ablyLinks}}}
+	cursor := resp.NextCursor()
+	if cursor == "" {
+		t.Fatal("no cursor for a page with a next link")
+	}
+	r := &Request{Query: url.Values{"limit": {"100"}, "direction": {"forwards"}}}
+	if err := r.ApplyCursor(cursor); err != nil {
+		t.Fatal(err)
+	}
+	want := url.Values{
+		"start":     {"0"},
+		"end":       {"1714566599000"},
+		"limit":     {"2"},
+		"direction": {"backwards"},
+		"format":    {"json"},
+		"serial":    {"abc:1"},
+	}
+	if !reflect.DeepEqual(r.Query, want) {
+		t.Errorf("query = %v, want %v", r.Query, want)
+	}
+
+	last := &Response{Header: http.Header{"Link": {`<./messages?limit=2>; rel="first", <./messages?limit=2>; rel="current"`}}}
+	if cursor := last.NextCursor(); cursor != "" {
+		t.Errorf("cursor of the last page = %q", cursor)
+	}
+	if err := r.ApplyCursor("not a cursor!"); err == nil || !strings.Contains(err.Error(), "invalid cursor") {
+		t.Errorf("ApplyCursor error = %v", err)
+	}
+}
+
+// pagedHost serves the items a page at a time, two per page, with Ably's
+// Link header.
+func pagedHost(t *testing.T, items []string) *testHost {
+	return newTestHost(t, func(w http.ResponseWriter, r *http.Request) {
+		var page int
+		fmt.Sscan(r.URL.Query().Get("page"), &page)
+		end := min(page*2+2, len(items))
+		links := fmt.Sprintf(`<./items?page=%d>; rel="first", <./items?page=%d>; rel="current"`, 0, page)
+		if end < len(items) {
+			links += fmt.Sprintf(`, <./items?page=%d>; rel="next"`, page+1)
+		}
+		w.Header().Set("Link", links)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `["%s"]`, strings.Join(items[page*2:end], `","`))
+	})
+}
+
+func TestPaginate(t *testing.T) {
+	h := pagedHost(t, []string{"a", "b", "c", "d", "e"})
+	c := NewClient(&config.APIConfig{BaseURL: h.URL, BasicAuth: "a2V5OnNlY3JldA=="})
+	ctx := context.Background()
+
+	// The cutoff returns the cursor of the first page not fetched
+	items, cursor, err := Paginate[string](ctx, c, &Request{Method: http.MethodGet, Path: "/items"}, "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(items, []string{"a", "b", "c", "d"}) || h.requests.Load() != 2 {
+		t.Errorf("items %v after %d requests, want two pages", items, h.requests.Load())
+	}
+	r := &Request{}
+	if err := r.ApplyCursor(cursor); err != nil || r.Query.Get("page") != "2" {
+		t.Errorf("cursor %q gives query %v, %v; want page 2", cursor, r.Query, err)
+	}
+
+	// Resuming from the cursor reads the rest
+	items, cursor, err = Paginate[string](ctx, c, &Request{Method: http.MethodGet, Path: "/items"}, cursor, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(items, []string{"e"}) || cursor != "" || h.requests.Load() != 3 {
+		t.Errorf("items %v, cursor %q after %d requests; want the last page", items, cursor, h.requests.Load())
+	}
+}
+
+func TestPaginationArgs(t *testing.T) {
+	tests := []struct {
+		args     map[string]any
+		cursor   string
+		maxPages int
+		err      bool
+	}{
+		{args: map[string]any{}, maxPages: 1},
+		{args: map[string]any{"cursor": "abc", "max_pages": float64(5)}, cursor: "abc", maxPages: 5},
+		{args: map[string]any{"max_pages": float64(MaxPages + 1)}, maxPages: MaxPages},
+		{args: map[string]any{"max_pages": float64(0)}, err: true},
+		{args: map[string]any{"max_pages": 1.5}, err: true},
+		{args: map[string]any{"cursor": 3}, err: true},
+	}
+	for _, tt := range tests {
+		cursor, maxPages, err := PaginationArgs(tt.args)
+		if (err != nil) != tt.err || cursor != tt.cursor || maxPages != tt.maxPages {
+			t.Errorf("PaginationArgs(%v) = %q, %d, %v", tt.args, cursor, maxPages, err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package ably
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/models"
+)
+
+// ToolResult returns v as MCP structured content together with an indented
+// JSON text rendering for clients that only read text content.
+func ToolResult(v interface{}) (*mcp.CallToolResult, error) {
+	prettyJSON, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+	}
+	return mcp.NewToolResultStructured(v, string(prettyJSON)), nil
+}
+
+// ListResult is the structured result of tools returning a list. MCP
+// structured content must be an object, so the items are wrapped. For
+// paginated endpoints NextCursor continues the listing.
+type ListResult[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ToolListResult returns items as a ListResult via ToolResult.
+func ToolListResult[T any](items []T) (*mcp.CallToolResult, error) {
+	return ToolPageResult(items, "")
+}
+
+// ToolPageResult returns a page of items and the cursor of the next page as
+// a ListResult via ToolResult.
+func ToolPageResult[T any](items []T, nextCursor string) (*mcp.CallToolResult, error) {
+	if items == nil {
+		items = []T{}
+	}
+	return ToolResult(ListResult[T]{Items: items, NextCursor: nextCursor})
+}
+
+// StatusResult is the structured result of operations whose response has no
+// body, such as deletions.
+type StatusResult struct {
+	StatusCode int    `json:"statusCode"`
+	Status     string `json:"status"`
+}
+
+// ToolStatusResult reports the status of a response without a body.
+func ToolStatusResult(resp *Response) (*mcp.CallToolResult, error) {
+	return ToolResult(StatusResult{
+		StatusCode: resp.StatusCode,
+		Status:     http.StatusText(resp.StatusCode),
+	})
+}
+
+// ErrorDetails is the structured content of a tool error caused by an Ably
+// error response, telling agents whether to retry, re-authenticate or give up.
+type ErrorDetails struct {
+	Error     models.Error  `json:"error"`
+	Category  ErrorCategory `json:"category"`
+	Retryable bool          `json:"retryable"`
+	Action    string        `json:"action"`
+}
+
+// ErrorResult converts an error returned by Client.Do into an MCP tool error.
+// Ably error responses additionally carry ErrorDetails as structured content.
+func ErrorResult(err error) *mcp.CallToolResult {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return mcp.NewToolResultErrorFromErr("Request timed out", err)
+	case errors.Is(err, context.Canceled):
+		return mcp.NewToolResultErrorFromErr("Request cancelled", err)
+	}
+	result := mcp.NewToolResultError(err.Error())
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		result.StructuredContent = ErrorDetails{
+			Error:     apiErr.Info,
+			Category:  apiErr.Category,
+			Retryable: apiErr.Retryable(),
+			Action:    apiErr.Action(),
+		}
+	}
+	return result
+}
@@ -0,0 +1,151 @@
+package ably
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// maxRetryDelay caps both the exponential backoff and Retry-After delays.
+const maxRetryDelay = 30 * time.Second
+
+// defaultFallbackHosts are Ably's fallback hosts for the primary REST host.
+var defaultFallbackHosts = []string{
+	"a.ably-realtime.com",
+	"b.ably-realtime.com",
+	"c.ably-realtime.com",
+	"d.ably-realtime.com",
+	"e.ably-realtime.com",
+}
+
+// networkError marks a request that failed before Ably answered it.
+type networkError struct {
+	err error
+}
+
+func (e *networkError) Error() string { return fmt.Sprintf("request failed: %v", e.err) }
+func (e *networkError) Unwrap() error { return e.err }
+
+// sendWithRetry sends r, retrying idempotent requests that failed with a
+// network error, a 5xx response or a rate limit. Network and server errors,
+// including attempts that time out, move on to the next fallback host; rate
+// limits stay on the current host and honor Retry-After.
+func (c *Client) sendWithRetry(ctx context.Context, r *Request) (*Response, error) {
+	hosts := c.hosts()
+	host := 0
+	var err error
+	for attempt := 0; ; attempt++ {
+		var resp *Response
+		resp, err = c.attempt(ctx, r, hosts[host])
+		if err == nil || attempt >= c.cfg.MaxRetries || !r.idempotent() || ctx.Err() != nil {
+			return resp, err
+		}
+
+		delay := c.backoff(attempt)
+		var apiErr *Error
+		var netErr *networkError
+		switch {
+		case errors.As(err, &apiErr) && apiErr.Category == CategoryRateLimit:
+			delay = max(delay, retryAfter(apiErr.Header))
+		case errors.As(err, &apiErr) && apiErr.Category == CategoryServer:
+			delay = max(delay, retryAfter(apiErr.Header))
+			host = (host + 1) % len(hosts)
+		case errors.As(err, &netErr):
+			host = (host + 1) % len(hosts)
+		default:
+			return nil, err
+		}
+
+		timer := time.NewTimer(min(delay, maxRetryDelay))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends r once to baseURL, within AttemptTimeout. An attempt that
+// times out while ctx is still live is a network error: the host did not
+// answer in time, and another one may.
+func (c *Client) attempt(ctx context.Context, r *Request, baseURL string) (*Response, error) {
+	timeout := c.cfg.AttemptTimeout
+	if timeout <= 0 {
+		return c.send(ctx, r, baseURL)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resp, err := c.send(attemptCtx, r, baseURL)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return nil, &networkError{err: fmt.Errorf("no response from %s within %v: %w", baseURL, timeout, context.DeadlineExceeded)}
+	}
+	return resp, err
+}
+
+// hosts returns the base URLs to try: the configured one first, followed by
+// the fallback hosts in random order so clients spread their retries.
+func (c *Client) hosts() []string {
+	hosts := []string{c.cfg.BaseURL}
+	base, err := url.Parse(c.cfg.BaseURL)
+	if err != nil {
+		return hosts
+	}
+	fallbacks := c.cfg.FallbackHosts
+	if len(fallbacks) == 0 && base.Host == "rest.ably.io" {
+		fallbacks = defaultFallbackHosts
+	}
+	for _, i := range rand.Perm(len(fallbacks)) {
+		u := *base
+		u.Host = fallbacks[i]
+		hosts = append(hosts, u.String())
+	}
+	return hosts
+}
+
+// backoff returns the jittered delay before retry number attempt+1: a random
+// duration between half and all of RetryBackoff * 2^attempt, capped at
+// maxRetryDelay. A zero RetryBackoff retries straight away.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.cfg.RetryBackoff
+	if delay <= 0 {
+		return 0
+	}
+	// Double step by step so that the delay stops at the cap rather than
+	// overflowing
+	for ; attempt > 0 && delay < maxRetryDelay; attempt-- {
+		delay *= 2
+	}
+	delay = min(delay, maxRetryDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// retryAfter parses the Retry-After header, given either in seconds or as
+// an HTTP date.
+func retryAfter(header http.Header) time.Duration {
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
+
+// idempotent reports whether r can safely be sent more than once.
+func (r *Request) idempotent() bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return r.Idempotent
+}
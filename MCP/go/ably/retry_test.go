@@ -0,0 +1,181 @@
+package ably
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/platform-api/mcp-server/config"
+)
+
+// testHost serves Ably requests with handler and counts them.
+type testHost struct {
+	*httptest.Server
+	requests atomic.Int32
+}
+
+func newTestHost(t *testing.T, handler http.HandlerFunc) *testHost {
+	h := &testHost{}
+	h.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.requests.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(h.Close)
+	return h
+}
+
+func (h *testHost) host() string {
+	u, _ := url.Parse(h.URL)
+	return u.Host
+}
+
+func ok(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{}`))
+}
+
+func retryConfig(primary *testHost, fallbacks ...*testHost) *config.APIConfig {
+	cfg := &config.APIConfig{
+		BaseURL:      primary.URL,
+		BasicAuth:    "a2V5OnNlY3JldA==",
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+	}
+	for _, h := range fallbacks {
+		cfg.FallbackHosts = append(cfg.FallbackHosts, h.host())
+	}
+	return cfg
+}
+
+func get(c *Client) (*Response, error) {
+	return c.Do(context.Background(), &Request{Method: http.MethodGet, Path: Path("time")})
+}
+
+func TestRetryMovesToFallbackHost(t *testing.T) {
+	primary := newTestHost(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	fallback := newTestHost(t, ok)
+
+	if _, err := get(NewClient(retryConfig(primary, fallback))); err != nil {
+		t.Fatal(err)
+	}
+	if n := primary.requests.Load(); n != 1 {
+		t.Errorf("primary host got %d requests, want 1", n)
+	}
+	if n := fallback.requests.Load(); n != 1 {
+		t.Errorf("fallback host got %d requests, want 1", n)
+	}
+}
+
+func TestAttemptTimeoutMovesToFallbackHost(t *testing.T) {
+	primary := newTestHost(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	fallback := newTestHost(t, ok)
+	cfg := retryConfig(primary, fallback)
+	cfg.AttemptTimeout = 50 * time.Millisecond
+
+	if _, err := get(NewClient(cfg)); err != nil {
+		t.Fatal(err)
+	}
+	if n := fallback.requests.Load(); n != 1 {
+		t.Errorf("fallback host got %d requests, want 1", n)
+	}
+}
+
+func TestAttemptTimeoutExhaustsRetries(t *testing.T) {
+	primary := newTestHost(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	cfg := retryConfig(primary)
+	cfg.AttemptTimeout = 20 * time.Millisecond
+
+	_, err := get(NewClient(cfg))
+	if err == nil {
+		t.Fatal("request to a host that never answers succeeded")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error %v does not report a timeout", err)
+	}
+	if n := primary.requests.Load(); n != 3 {
+		t.Errorf("host got %d requests, want 3", n)
+	}
+}
+
+func TestRetryAfterStaysOnHost(t *testing.T) {
+	var limited atomic.Bool
+	primary := newTestHost(t, func(w http.ResponseWriter, r *http.Request) {
+		if limited.CompareAndSwap(false, true) {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		ok(w, r)
+	})
+	fallback := newTestHost(t, ok)
+
+	start := time.Now()
+	if _, err := get(NewClient(retryConfig(primary, fallback))); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, before Retry-After", elapsed)
+	}
+	if n := primary.requests.Load(); n != 2 {
+		t.Errorf("primary host got %d requests, want 2", n)
+	}
+	if n := fallback.requests.Load(); n != 0 {
+		t.Errorf("fallback host got %d requests, want 0", n)
+	}
+}
+
+func TestNonIdempotentRequestIsNotRetried(t *testing.T) {
+	primary := newTestHost(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	fallback := newTestHost(t, ok)
+
+	_, err := NewClient(retryConfig(primary, fallback)).Do(context.Background(), &Request{
+		Method: http.MethodPost,
+		Path:   Path("channels", "c", "messages"),
+		Body:   map[string]string{"name": "n"},
+	})
+	if err == nil {
+		t.Fatal("publish to a failing host succeeded")
+	}
+	if n := primary.requests.Load() + fallback.requests.Load(); n != 1 {
+		t.Errorf("hosts got %d requests, want 1", n)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		base     time.Duration
+		attempt  int
+		min, max time.Duration
+	}{
+		{0, 0, 0, 0},
+		{0, 5, 0, 0},
+		{100 * time.Millisecond, 0, 50 * time.Millisecond, 100 * time.Millisecond},
+		{100 * time.Millisecond, 3, 400 * time.Millisecond, 800 * time.Millisecond},
+		{time.Second, 10, maxRetryDelay / 2, maxRetryDelay},
+		// A shift this far would overflow
+		{time.Second, 70, maxRetryDelay / 2, maxRetryDelay},
+		{time.Minute, 0, maxRetryDelay / 2, maxRetryDelay},
+	}
+	for _, tt := range tests {
+		c := NewClient(&config.APIConfig{RetryBackoff: tt.base})
+		for range 20 {
+			if got := c.backoff(tt.attempt); got < tt.min || got > tt.max {
+				t.Errorf("backoff(%d) with base %v = %v, want within [%v, %v]", tt.attempt, tt.base, got, tt.min, tt.max)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package ably
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/platform-api/mcp-server/models"
+)
+
+// minNonceLength is the shortest nonce Ably accepts in a TokenRequest.
+const minNonceLength = 16
+
+// SignTokenRequest builds a SignedTokenRequest for keyName from params
+// without contacting Ably. params is completed by PrepareTokenRequest and
+// signed with keySecret using the HMAC-SHA256 scheme from the Ably
+// TokenRequest spec. The result can be exchanged for a token by anyone
+// holding it, without knowing the key secret.
+func SignTokenRequest(keyName, keySecret string, params models.TokenRequest, now time.Time) (*models.SignedTokenRequest, error) {
+	if keyName == "" || keySecret == "" {
+		return nil, fmt.Errorf("key name and key secret are required to sign a token request")
+	}
+	if params.Keyname != "" && params.Keyname != keyName {
+		return nil, fmt.Errorf("token request is for key %s, not %s", params.Keyname, keyName)
+	}
+	if err := PrepareTokenRequest(&params, now); err != nil {
+		return nil, err
+	}
+
+	// The request is sent with this same text, see models.CapabilityText
+	capability, err := models.CapabilityText(params.Capability)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := ""
+	if params.Ttl > 0 {
+		ttl = strconv.FormatInt(params.Ttl, 10)
+	}
+	var signText strings.Builder
+	for _, field := range []string{
+		keyName,
+		ttl,
+		capability,
+		params.Clientid,
+		strconv.Itoa(params.Timestamp),
+		params.Nonce,
+	} {
+		signText.WriteString(field)
+		signText.WriteByte('\n')
+	}
+
+	mac := hmac.New(sha256.New, []byte(keySecret))
+	mac.Write([]byte(signText.String()))
+
+	return &models.SignedTokenRequest{
+		Keyname:    keyName,
+		Nonce:      params.Nonce,
+		Timestamp:  params.Timestamp,
+		Capability: params.Capability,
+		Clientid:   params.Clientid,
+		Ttl:        params.Ttl,
+		Mac:        base64.StdEncoding.EncodeToString(mac.Sum(nil)),
+	}, nil
+}
+
+// PrepareTokenRequest fills in the defaults of a TokenRequest and validates
+// it: a missing nonce is generated, a missing timestamp is taken from now, a
+// missing capability grants everything the key allows, the nonce and ttl
+// are range checked and the capability is validated and normalized.
+func PrepareTokenRequest(params *models.TokenRequest, now time.Time) error {
+	if params.Nonce == "" {
+		nonce, err := Nonce()
+		if err != nil {
+			return err
+		}
+		params.Nonce = nonce
+	} else if len(params.Nonce) < minNonceLength {
+		return fmt.Errorf("nonce must be at least %d characters", minNonceLength)
+	}
+	if params.Timestamp == 0 {
+		params.Timestamp = int(now.UnixMilli())
+	} else if params.Timestamp < 0 {
+		return fmt.Errorf("timestamp must be milliseconds since the epoch")
+	}
+	if params.Ttl < 0 {
+		return fmt.Errorf("ttl must not be negative")
+	}
+	if params.Capability == nil {
+		params.Capability = map[string]interface{}{"*": []string{"*"}}
+		return nil
+	}
+	capability, err := ParseCapability(params.Capability)
+	if err != nil {
+		return err
+	}
+	params.Capability = capability.Map()
+	return nil
+}
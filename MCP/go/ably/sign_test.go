@@ -0,0 +1,76 @@
+package ably
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/platform-api/mcp-server/models"
+)
+
+// The MAC was computed independently as the base64 HMAC-SHA256, keyed with
+// the secret, of keyName, ttl, capability, clientId, timestamp and nonce,
+// each followed by a newline.
+func TestSignTokenRequestKnownAnswer(t *testing.T) {
+	const (
+		capabilityText = `{"chat:*":["publish","subscribe"],"status":["presence"]}`
+		wantMac        = "OTDFvQ2iYP2RiBzsnpfRcGeGvPH5Ucg6vlG0gyGoVvM="
+	)
+	signed, err := SignTokenRequest("appid.keyid", "s3cr3t", models.TokenRequest{
+		Nonce:     "0123456789abcdef",
+		Timestamp: 1700000000000,
+		Capability: map[string]interface{}{
+			"status": []string{"presence"},
+			"chat:*": []string{"subscribe", "publish"},
+		},
+		Clientid: "bob",
+		Ttl:      3600000,
+	}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed.Mac != wantMac {
+		t.Errorf("mac = %s, want %s", signed.Mac, wantMac)
+	}
+
+	// The request must carry the capability as the exact text signed
+	b, err := json.Marshal(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent map[string]any
+	if err := json.Unmarshal(b, &sent); err != nil {
+		t.Fatal(err)
+	}
+	if sent["capability"] != capabilityText {
+		t.Errorf("capability sent as %#v, want %q", sent["capability"], capabilityText)
+	}
+
+	// and reads back from that text unchanged
+	var back models.SignedTokenRequest
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := models.CapabilityText(back.Capability); text != capabilityText {
+		t.Errorf("capability read back as %s, want %s", text, capabilityText)
+	}
+}
+
+func TestTokenRequestCapabilityForms(t *testing.T) {
+	for _, body := range []string{
+		`{"keyName":"a.b","nonce":"n","timestamp":1,"capability":{"ch":["publish"]}}`,
+		`{"keyName":"a.b","nonce":"n","timestamp":1,"capability":"{\"ch\":[\"publish\"]}"}`,
+	} {
+		var r models.TokenRequest
+		if err := json.Unmarshal([]byte(body), &r); err != nil {
+			t.Fatalf("%s: %v", body, err)
+		}
+		if r.Keyname != "a.b" || r.Timestamp != 1 {
+			t.Errorf("%s: fields not decoded: %+v", body, r)
+		}
+		b, _ := json.Marshal(r)
+		if want := `{"nonce":"n","timestamp":1,"keyName":"a.b","capability":"{\"ch\":[\"publish\"]}"}`; string(b) != want {
+			t.Errorf("%s: encoded as %s, want %s", body, b, want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package ably
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// TimeLayout renders timestamps in results: RFC 3339 in UTC with
+// millisecond precision, matching the resolution of Ably timestamps.
+const TimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// FormatTimestamp renders milliseconds since the epoch with TimeLayout, or
+// returns "" for a zero timestamp.
+func FormatTimestamp(ms int64) string {
+	if ms == 0 {
+		return ""
+	}
+	return time.UnixMilli(ms).UTC().Format(TimeLayout)
+}
+
+// TimeRangeArgs parses the start and end arguments with ParseTime, checks
+// that start is not after end and sets them on query as milliseconds since
+// the epoch, the form Ably expects.
+func TimeRangeArgs(args map[string]any, query url.Values, now time.Time) error {
+	var start, end time.Time
+	for _, bound := range []struct {
+		name string
+		t    *time.Time
+	}{{"start", &start}, {"end", &end}} {
+		val, ok := args[bound.name]
+		if !ok {
+			continue
+		}
+		t, err := ParseTime(val, now)
+		if err != nil {
+			return fmt.Errorf("invalid parameter %s: %v", bound.name, err)
+		}
+		*bound.t = t
+		query.Set(bound.name, strconv.FormatInt(t.UnixMilli(), 10))
+	}
+	if !start.IsZero() && !end.IsZero() && start.After(end) {
+		return fmt.Errorf("invalid time range: start %s is after end %s", start.UTC().Format(TimeLayout), end.UTC().Format(TimeLayout))
+	}
+	return nil
+}
+
+// minEpochMillis is the earliest time accepted in epoch milliseconds,
+// 2000-01-01T00:00:00Z. Smaller values are more likely seconds, or offsets
+// missing their unit, than times.
+const minEpochMillis = 946684800000
+
+// relativeUnits holds the units of relative times beyond those of
+// time.ParseDuration.
+var relativeUnits = map[string]time.Duration{
+	"w": 7 * 24 * time.Hour,
+	"d": 24 * time.Hour,
+}
+
+// ParseTime parses a point in time given as milliseconds since the epoch,
+// an RFC 3339 timestamp or date, or an expression relative to now: "now",
+// "today", "yesterday", or a signed duration such as "-15m", "-2h30m" or
+// "-7d" where d stands for days and w for weeks. Days are calendar days in
+// UTC. Epoch times before 2000 are rejected, as is a signed number without
+// a unit.
+func ParseTime(v any, now time.Time) (time.Time, error) {
+	var s string
+	switch v := v.(type) {
+	case float64:
+		return epochMillis(int64(v))
+	case string:
+		s = strings.TrimSpace(v)
+	default:
+		return time.Time{}, fmt.Errorf("expected a string or a number, got %T", v)
+	}
+
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		d, err := parseRelative(s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%q is not a relative time such as -15m or -1d12h: %v", s, err)
+		}
+		return now.Add(d), nil
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return epochMillis(ms)
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	today := now.UTC().Truncate(24 * time.Hour)
+	switch strings.ToLower(s) {
+	case "now":
+		return now, nil
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not epoch milliseconds, an RFC 3339 time or a relative time such as -15m", s)
+}
+
+func epochMillis(ms int64) (time.Time, error) {
+	if ms < minEpochMillis {
+		return time.Time{}, fmt.Errorf("%d is not a plausible time in epoch milliseconds, which must not be before 2000; use a relative time such as -15m for an offset from now", ms)
+	}
+	return time.UnixMilli(ms), nil
+}
+
+// parseRelative parses a signed duration made of numbers each followed by
+// a unit, in any order: w for weeks, d for days, or any unit of
+// time.ParseDuration.
+func parseRelative(s string) (time.Duration, error) {
+	if !strings.HasPrefix(s, "-") && !strings.HasPrefix(s, "+") {
+		return 0, fmt.Errorf("relative time must start with - or +")
+	}
+	sign, rest := s[:1], s[1:]
+	if rest == "" {
+		return 0, fmt.Errorf("missing duration")
+	}
+	isNumber := func(r rune) bool { return unicode.IsDigit(r) || r == '.' }
+	var total time.Duration
+	for rest != "" {
+		i := strings.IndexFunc(rest, func(r rune) bool { return !isNumber(r) })
+		switch {
+		case i == 0:
+			return 0, fmt.Errorf("expected a number at %q", rest)
+		case i < 0:
+			return 0, fmt.Errorf("missing unit after %s", rest)
+		}
+		n := strings.IndexFunc(rest[i:], isNumber)
+		if n < 0 {
+			n = len(rest) - i
+		}
+		number, unit := rest[:i], rest[i:i+n]
+		rest = rest[i+n:]
+
+		if d, ok := relativeUnits[unit]; ok {
+			f, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid number %q", number)
+			}
+			total += time.Duration(f * float64(d))
+			continue
+		}
+		d, err := time.ParseDuration(number + unit)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q, expected a number followed by w, d, h, m, s or ms", number+unit)
+		}
+		total += d
+	}
+	if sign == "-" {
+		total = -total
+	}
+	return total, nil
+}
@@ -0,0 +1,108 @@
+package ably
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRelative(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		err  string
+	}{
+		{in: "-15m", want: -15 * time.Minute},
+		{in: "+90s", want: 90 * time.Second},
+		{in: "-2h30m", want: -150 * time.Minute},
+		{in: "-7d", want: -7 * 24 * time.Hour},
+		{in: "-1w", want: -7 * 24 * time.Hour},
+		{in: "-1d2h", want: -26 * time.Hour},
+		{in: "-2h1d", want: -26 * time.Hour},
+		{in: "-1w2d3h", want: -(9*24 + 3) * time.Hour},
+		{in: "-1.5d", want: -36 * time.Hour},
+		{in: "-500ms", want: -500 * time.Millisecond},
+		{in: "15m", err: "must start with - or +"},
+		{in: "-", err: "missing duration"},
+		{in: "-5", err: "missing unit"},
+		{in: "-1d5", err: "missing unit"},
+		{in: "-h", err: "expected a number"},
+		{in: "-3x", err: "invalid duration"},
+		{in: "-1..5h", err: "invalid duration"},
+	}
+	for _, tt := range tests {
+		got, err := parseRelative(tt.in)
+		switch {
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("parseRelative(%q) error = %v, want %q", tt.in, err, tt.err)
+		case tt.err == "" && err != nil:
+			t.Errorf("parseRelative(%q) error = %v", tt.in, err)
+		case tt.err == "" && got != tt.want:
+			t.Errorf("parseRelative(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	tests := []struct {
+		in   any
+		want time.Time
+		err  string
+	}{
+		{in: "1714566600000", want: now},
+		{in: float64(1714566600000), want: now},
+		{in: "2024-05-01T12:30:00Z", want: now},
+		{in: "2024-05-01T14:30:00+02:00", want: now},
+		{in: "2024-05-01T12:30:00", want: now},
+		{in: "2024-05-01", want: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{in: " now ", want: now},
+		{in: "Today", want: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{in: "yesterday", want: time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)},
+		{in: "-1h", want: now.Add(-time.Hour)},
+		{in: "-2h1d", want: now.Add(-26 * time.Hour)},
+		{in: "+1d", want: now.Add(24 * time.Hour)},
+		{in: "946684800000", want: time.UnixMilli(minEpochMillis)},
+		{in: "1714566600", err: "not a plausible time"},
+		{in: "12", err: "not a plausible time"},
+		{in: "0", err: "not a plausible time"},
+		{in: float64(-1), err: "not a plausible time"},
+		{in: "-5", err: "not a relative time"},
+		{in: "+30", err: "not a relative time"},
+		{in: "-1714566600000", err: "not a relative time"},
+		{in: "last week", err: "is not epoch milliseconds"},
+		{in: true, err: "expected a string or a number"},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.in, now)
+		switch {
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("ParseTime(%#v) error = %v, want %q", tt.in, err, tt.err)
+		case tt.err == "" && err != nil:
+			t.Errorf("ParseTime(%#v) error = %v", tt.in, err)
+		case tt.err == "" && !got.Equal(tt.want):
+			t.Errorf("ParseTime(%#v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTimeRangeArgs(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	query := url.Values{}
+	if err := TimeRangeArgs(map[string]any{"start": "-1h", "end": "now"}, query, now); err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("start") != "1714563000000" || query.Get("end") != "1714566600000" {
+		t.Errorf("query = %v", query)
+	}
+
+	err := TimeRangeArgs(map[string]any{"start": "now", "end": "-1h"}, url.Values{}, now)
+	if err == nil || !strings.Contains(err.Error(), "is after end") {
+		t.Errorf("reversed range error = %v", err)
+	}
+	err = TimeRangeArgs(map[string]any{"end": "-5"}, url.Values{}, now)
+	if err == nil || !strings.Contains(err.Error(), "invalid parameter end") {
+		t.Errorf("unitless end error = %v", err)
+	}
+}
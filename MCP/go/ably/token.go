@@ -0,0 +1,200 @@
+package ably
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/platform-api/mcp-server/models"
+)
+
+const (
+	// DefaultTokenTTL is the lifetime requested for tokens minted from an
+	// API key when the configuration does not specify one.
+	DefaultTokenTTL = time.Hour
+
+	// tokenRenewMargin is how long before expiry a cached token is renewed,
+	// leaving room for clock skew and in-flight requests.
+	tokenRenewMargin = 30 * time.Second
+)
+
+// tokenCache holds the tokens minted for each API key and endpoint, keyed
+// by tokenCacheKey. It is shared by all clients because HTTP mode builds a
+// fresh configuration per MCP session. Entries are only kept while they
+// hold a token that has not expired.
+var tokenCache = struct {
+	sync.Mutex
+	entries map[string]*cachedToken
+}{entries: make(map[string]*cachedToken)}
+
+type cachedToken struct {
+	key     string
+	mu      sync.Mutex
+	details *models.TokenDetails
+	expires atomic.Int64 // Expiry of details in milliseconds since the epoch, readable without mu.
+}
+
+// RequestToken exchanges tokenRequest for an Ably Token by calling
+// /keys/{keyName}/requestToken. Unsigned requests must be authenticated with
+// the key itself, so the configured API key is used as basic auth when
+// present instead of a token minted from it.
+func (c *Client) RequestToken(ctx context.Context, tokenRequest *models.TokenRequest) (*models.TokenDetails, error) {
+	r := &Request{
+		Method: http.MethodPost,
+		Path:   Path("keys", tokenRequest.Keyname, "requestToken"),
+		Body:   tokenRequest,
+	}
+	if c.cfg.APIKey != "" {
+		r.auth = authKey
+	}
+	return c.requestToken(ctx, r)
+}
+
+// RequestSignedToken exchanges a SignedTokenRequest for an Ably Token. The
+// signature authenticates the request, so no credentials are sent.
+func (c *Client) RequestSignedToken(ctx context.Context, signed *models.SignedTokenRequest) (*models.TokenDetails, error) {
+	return c.requestToken(ctx, &Request{
+		Method: http.MethodPost,
+		Path:   Path("keys", signed.Keyname, "requestToken"),
+		Body:   signed,
+		auth:   authNone,
+	})
+}
+
+func (c *Client) requestToken(ctx context.Context, r *Request) (*models.TokenDetails, error) {
+	resp, err := c.Do(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	var details models.TokenDetails
+	if err := resp.Decode(&details); err != nil {
+		return nil, fmt.Errorf("failed to decode token details: %w", err)
+	}
+	if details.Token == "" {
+		return nil, fmt.Errorf("token response did not contain a token")
+	}
+	return &details, nil
+}
+
+// KeyCapability returns the capability of the configured API key, as Ably
+// reports it for the token minted from the key.
+func (c *Client) KeyCapability(ctx context.Context) (Capability, error) {
+	if c.cfg.APIKey == "" {
+		return nil, fmt.Errorf("no API key configured")
+	}
+	details, err := c.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeyCapability(details.Capability)
+}
+
+// token returns valid token details for the configured API key, minting a
+// new token when none is cached or the cached one is about to expire.
+func (c *Client) token(ctx context.Context) (*models.TokenDetails, error) {
+	entry := c.cachedToken()
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.details != nil && msToTime(entry.details.Expires).Sub(c.Now()) > tokenRenewMargin {
+		return entry.details, nil
+	}
+
+	keyName, keySecret, err := SplitKey(c.cfg.APIKey)
+	if err != nil {
+		dropCachedToken(entry)
+		return nil, err
+	}
+	ttl := c.cfg.TokenTTL
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+	// Sign locally so the key secret never leaves the server
+	signed, err := SignTokenRequest(keyName, keySecret, models.TokenRequest{
+		Ttl: ttl.Milliseconds(),
+	}, c.Now())
+	if err != nil {
+		dropCachedToken(entry)
+		return nil, err
+	}
+	details, err := c.RequestSignedToken(ctx, signed)
+	if err != nil {
+		dropCachedToken(entry)
+		return nil, fmt.Errorf("failed to obtain token for key %s: %w", keyName, err)
+	}
+	entry.details = details
+	entry.expires.Store(int64(details.Expires))
+	return details, nil
+}
+
+// invalidateToken drops the cached token for the configured API key so the
+// next request mints a new one.
+func (c *Client) invalidateToken() {
+	entry := c.cachedToken()
+	entry.mu.Lock()
+	entry.details = nil
+	entry.expires.Store(0)
+	entry.mu.Unlock()
+	dropCachedToken(entry)
+}
+
+// cachedToken returns the cache entry for the configured API key and base
+// URL, creating it when there is none. Creating an entry first evicts those
+// whose token has expired, so that keys no longer in use do not pile up.
+func (c *Client) cachedToken() *cachedToken {
+	key := tokenCacheKey(c.cfg.BaseURL, c.cfg.APIKey)
+	tokenCache.Lock()
+	defer tokenCache.Unlock()
+	entry, ok := tokenCache.entries[key]
+	if !ok {
+		now := time.Now().UnixMilli()
+		for k, e := range tokenCache.entries {
+			if expires := e.expires.Load(); expires != 0 && expires <= now {
+				delete(tokenCache.entries, k)
+			}
+		}
+		entry = &cachedToken{key: key}
+		tokenCache.entries[key] = entry
+	}
+	return entry
+}
+
+// dropCachedToken removes entry from the cache, unless it has been replaced
+// already.
+func dropCachedToken(entry *cachedToken) {
+	tokenCache.Lock()
+	defer tokenCache.Unlock()
+	if tokenCache.entries[entry.key] == entry {
+		delete(tokenCache.entries, entry.key)
+	}
+}
+
+// tokenCacheKey identifies the tokens minted from apiKey against baseURL.
+// The key is hashed so that secrets are not kept as map keys, and whole so
+// that a request giving a known key name with a wrong secret cannot reuse
+// the token minted with the right one.
+func tokenCacheKey(baseURL, apiKey string) string {
+	sum := sha256.Sum256([]byte(baseURL + "\n" + apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// Nonce returns a random string suitable for TokenRequest.Nonce, which Ably
+// requires to be at least 16 characters long.
+func Nonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func msToTime(ms int) time.Time {
+	return time.UnixMilli(int64(ms))
+}
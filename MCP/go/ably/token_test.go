@@ -0,0 +1,96 @@
+package ably
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/platform-api/mcp-server/config"
+)
+
+// tokenHost mints tokens expiring after ttl, or fails with status when it
+// is not zero.
+func tokenHost(t *testing.T, ttl time.Duration, status int) *testHost {
+	return newTestHost(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/requestToken") {
+			ok(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if status != 0 {
+			w.WriteHeader(status)
+			fmt.Fprintf(w, `{"error":{"code":40100,"statusCode":%d,"message":"denied"}}`, status)
+			return
+		}
+		fmt.Fprintf(w, `{"token":"t","expires":%d}`, time.Now().Add(ttl).UnixMilli())
+	})
+}
+
+func cached(baseURL, apiKey string) (*cachedToken, bool) {
+	tokenCache.Lock()
+	defer tokenCache.Unlock()
+	entry, ok := tokenCache.entries[tokenCacheKey(baseURL, apiKey)]
+	return entry, ok
+}
+
+func TestTokenCacheKey(t *testing.T) {
+	key := tokenCacheKey("https://rest.ably.io", "app.key:secret")
+	if strings.Contains(key, "secret") {
+		t.Errorf("cache key %q holds the key secret", key)
+	}
+	for _, other := range []string{
+		tokenCacheKey("https://sandbox-rest.ably.io", "app.key:secret"),
+		tokenCacheKey("https://rest.ably.io", "app.key:other"),
+	} {
+		if other == key {
+			t.Errorf("cache key %q shared across endpoints or secrets", key)
+		}
+	}
+}
+
+func TestTokenReusedUntilExpiry(t *testing.T) {
+	h := tokenHost(t, time.Hour, 0)
+	c := NewClient(&config.APIConfig{BaseURL: h.URL, APIKey: "app.key:secret"})
+	for range 2 {
+		if _, err := get(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// One token request and two calls
+	if got := h.requests.Load(); got != 3 {
+		t.Errorf("%d requests, want 3", got)
+	}
+	if _, ok := cached(h.URL, "app.key:secret"); !ok {
+		t.Error("token not cached")
+	}
+}
+
+func TestTokenCacheEvictsExpired(t *testing.T) {
+	h := tokenHost(t, -time.Minute, 0)
+	c := NewClient(&config.APIConfig{BaseURL: h.URL, APIKey: "app.key:expired"})
+	if _, err := get(c); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cached(h.URL, "app.key:expired"); !ok {
+		t.Fatal("token not cached")
+	}
+	// Caching the token of another key drops the expired one
+	other := NewClient(&config.APIConfig{BaseURL: h.URL, APIKey: "app.key:other"})
+	other.cachedToken()
+	if _, ok := cached(h.URL, "app.key:expired"); ok {
+		t.Error("expired token still cached")
+	}
+}
+
+func TestTokenCacheDropsFailedKey(t *testing.T) {
+	h := tokenHost(t, 0, http.StatusUnauthorized)
+	c := NewClient(&config.APIConfig{BaseURL: h.URL, APIKey: "app.key:wrong"})
+	if _, err := get(c); err == nil {
+		t.Fatal("call with a rejected key succeeded")
+	}
+	if _, ok := cached(h.URL, "app.key:wrong"); ok {
+		t.Error("rejected key left in the cache")
+	}
+}
@@ -0,0 +1,127 @@
+package ably
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/platform-api/mcp-server/config"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Content types of the wire formats.
+const (
+	contentTypeJSON    = "application/json"
+	contentTypeMsgpack = "application/x-msgpack"
+)
+
+// Bodies are encoded as msgpack by way of their JSON encoding, so that the
+// json struct tags of the models apply to both formats. Message payloads
+// travel as msgpack binary rather than base64 strings: encodeBody turns a
+// data field whose encoding ends in base64 into binary, and decodeBody
+// turns binary data back into a base64 string, which is how binary
+// payloads are represented everywhere else.
+
+// encodeBody encodes body in the given wire format and returns it with
+// its content type. Bodies that cannot be encoded as msgpack are sent as
+// JSON instead.
+func encodeBody(format string, body interface{}) ([]byte, string, error) {
+	if format == config.FormatMsgpack {
+		if encoded, err := encodeMsgpack(body); err == nil {
+			return encoded, contentTypeMsgpack, nil
+		}
+	}
+	encoded, err := json.Marshal(body)
+	return encoded, contentTypeJSON, err
+}
+
+func encodeMsgpack(body interface{}) ([]byte, error) {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return msgpack.Marshal(toMsgpack(generic))
+}
+
+// toMsgpack prepares a value decoded from JSON for msgpack encoding.
+func toMsgpack(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i := range v {
+			v[i] = toMsgpack(v[i])
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = toMsgpack(v[k])
+		}
+		data, isString := v["data"].(string)
+		encoding, _ := v["encoding"].(string)
+		if isString && lastStep(encoding) == EncodingBase64 {
+			if b, err := base64.StdEncoding.DecodeString(data); err == nil {
+				v["data"] = b
+				v["encoding"] = strings.TrimSuffix(strings.TrimSuffix(encoding, EncodingBase64), "/")
+				if v["encoding"] == "" {
+					delete(v, "encoding")
+				}
+			}
+		}
+	}
+	return v
+}
+
+// decodeBody unmarshals a body, encoded in the format given by the
+// Content-Type in header, into v.
+func decodeBody(header http.Header, body []byte, v interface{}) error {
+	if !isMsgpack(header) {
+		return json.Unmarshal(body, v)
+	}
+	var generic interface{}
+	if err := msgpack.Unmarshal(body, &generic); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(fromMsgpack(generic))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// fromMsgpack prepares a value decoded from msgpack for JSON encoding.
+func fromMsgpack(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		for i := range v {
+			v[i] = fromMsgpack(v[i])
+		}
+	case map[string]interface{}:
+		if data, ok := v["data"].([]byte); ok {
+			encoding, _ := v["encoding"].(string)
+			v["data"] = base64.StdEncoding.EncodeToString(data)
+			v["encoding"] = appendStep(encoding, EncodingBase64)
+		}
+		for k := range v {
+			v[k] = fromMsgpack(v[k])
+		}
+	}
+	return v
+}
+
+func isMsgpack(header http.Header) bool {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	return mediaType == contentTypeMsgpack
+}
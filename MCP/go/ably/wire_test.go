@@ -0,0 +1,154 @@
+package ably
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/platform-api/mcp-server/config"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func msgpackHeader() http.Header {
+	return http.Header{"Content-Type": {contentTypeMsgpack}}
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		// The message as the tools see it, and its data and encoding on
+		// the msgpack wire
+		message  map[string]interface{}
+		wireData interface{}
+		wireEnc  interface{}
+	}{
+		{
+			name:     "text",
+			message:  map[string]interface{}{"name": "greeting", "data": "hello"},
+			wireData: "hello",
+		},
+		{
+			name:     "binary",
+			message:  map[string]interface{}{"data": "AAEC/w==", "encoding": "base64"},
+			wireData: []byte{0, 1, 2, 255},
+		},
+		{
+			name:     "encrypted",
+			message:  map[string]interface{}{"data": "AAEC/w==", "encoding": "utf-8/cipher+aes-128-cbc/base64"},
+			wireData: []byte{0, 1, 2, 255},
+			wireEnc:  "utf-8/cipher+aes-128-cbc",
+		},
+		{
+			name:     "json",
+			message:  map[string]interface{}{"data": `{"a":1}`, "encoding": "json"},
+			wireData: `{"a":1}`,
+			wireEnc:  "json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType, err := encodeBody(config.FormatMsgpack, []map[string]interface{}{tt.message})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if contentType != contentTypeMsgpack {
+				t.Errorf("content type %q", contentType)
+			}
+			var wire []map[string]interface{}
+			if err := msgpack.Unmarshal(body, &wire); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(wire[0]["data"], tt.wireData) || wire[0]["encoding"] != tt.wireEnc {
+				t.Errorf("on the wire: data %#v, encoding %#v; want %#v, %#v", wire[0]["data"], wire[0]["encoding"], tt.wireData, tt.wireEnc)
+			}
+
+			var decoded []map[string]interface{}
+			if err := decodeBody(msgpackHeader(), body, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded[0], tt.message) {
+				t.Errorf("decoded %v, want %v", decoded[0], tt.message)
+			}
+		})
+	}
+}
+
+func TestMsgpackNumbers(t *testing.T) {
+	body, _, err := encodeBody(config.FormatMsgpack, map[string]interface{}{"count": 3, "ratio": 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wire map[string]interface{}
+	if err := msgpack.Unmarshal(body, &wire); err != nil {
+		t.Fatal(err)
+	}
+	// Whole numbers stay integers rather than becoming floats
+	if !reflect.ValueOf(wire["count"]).CanInt() || wire["ratio"] != 0.5 {
+		t.Errorf("on the wire: %#v", wire)
+	}
+}
+
+func TestMsgpackErrorBody(t *testing.T) {
+	body, err := msgpack.Marshal(map[string]interface{}{
+		"error": map[string]interface{}{"code": 40160, "statusCode": 401, "message": "action not permitted"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := newError(http.StatusUnauthorized, msgpackHeader(), body)
+	if e.Info.Code != 40160 || e.Info.Message != "action not permitted" || e.Category != CategoryAuth {
+		t.Errorf("error = %+v", e.Info)
+	}
+
+	// An undecodable msgpack body is not shown as the message
+	e = newError(http.StatusInternalServerError, msgpackHeader(), []byte{0xc1})
+	if e.Info.Message != http.StatusText(http.StatusInternalServerError) {
+		t.Errorf("message = %q", e.Info.Message)
+	}
+}
+
+func TestMsgpackFallsBackToJSON(t *testing.T) {
+	var contentTypes []string
+	h := newTestHost(t, func(w http.ResponseWriter, r *http.Request) {
+		contentTypes = append(contentTypes, r.Header.Get("Content-Type"))
+		if r.Header.Get("Content-Type") == contentTypeMsgpack {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		var messages []map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&messages); err != nil || messages[0]["data"] != "AAEC/w==" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", contentTypeJSON)
+		w.Write([]byte(`{"channel":"chat","messageId":"abc"}`))
+	})
+	c := NewClient(&config.APIConfig{BaseURL: h.URL, BasicAuth: "a2V5OnNlY3JldA==", Format: config.FormatMsgpack})
+	resp, err := c.Do(context.Background(), &Request{
+		Method: http.MethodPost,
+		Path:   Path("channels", "chat", "messages"),
+		Body:   []map[string]interface{}{{"data": "AAEC/w==", "encoding": "base64"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{contentTypeMsgpack, contentTypeJSON}; !reflect.DeepEqual(contentTypes, want) {
+		t.Errorf("content types %v, want %v", contentTypes, want)
+	}
+	var result map[string]string
+	if err := resp.Decode(&result); err != nil || result["messageId"] != "abc" {
+		t.Errorf("result %v, error %v", result, err)
+	}
+}
+
+func TestDecodeBodyJSON(t *testing.T) {
+	var v map[string]interface{}
+	if err := decodeBody(http.Header{"Content-Type": {"application/json; charset=utf-8"}}, []byte(`{"data":"AAEC/w=="}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v["data"] != "AAEC/w==" || v["encoding"] != nil {
+		t.Errorf("decoded %v", v)
+	}
+}
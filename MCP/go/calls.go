@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/platform-api/mcp-server/config"
+)
+
+// callIDField is the _meta field used to hand the JSON-RPC request ID of a
+// tool call from the before-call hook to the tool middleware, which
+// otherwise has no access to it.
+const callIDField = "platform-api/callId"
+
+// callTracker bounds every tool call by its configured deadline and cancels
+// it when the client sends notifications/cancelled, so that abandoned calls
+// stop waiting on Ably instead of pinning a goroutine. In HTTP mode each
+// request is served by its own MCP server, and a cancellation arrives in a
+// different request than the call it names, so a single tracker is shared
+// by all of them and calls are keyed by session.
+type callTracker struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+func newCallTracker() *callTracker {
+	return &callTracker{
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// tagCall records the request ID of a tool call in its _meta.
+func (t *callTracker) tagCall(ctx context.Context, id any, message *mcp.CallToolRequest) {
+	if message.Params.Meta == nil {
+		message.Params.Meta = &mcp.Meta{}
+	}
+	if message.Params.Meta.AdditionalFields == nil {
+		message.Params.Meta.AdditionalFields = make(map[string]any)
+	}
+	message.Params.Meta.AdditionalFields[callIDField] = callKey(ctx, id)
+}
+
+// middleware applies the call deadline configured in cfg and makes the call
+// cancellable.
+func (t *callTracker) middleware(cfg *config.APIConfig) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var cancel context.CancelFunc
+			if timeout := cfg.TimeoutFor(request.Params.Name); timeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+			} else {
+				ctx, cancel = context.WithCancel(ctx)
+			}
+			defer cancel()
+
+			if meta := request.Params.Meta; meta != nil {
+				if key, ok := meta.AdditionalFields[callIDField].(string); ok {
+					delete(meta.AdditionalFields, callIDField)
+					t.mu.Lock()
+					t.cancels[key] = cancel
+					t.mu.Unlock()
+					defer func() {
+						t.mu.Lock()
+						delete(t.cancels, key)
+						t.mu.Unlock()
+					}()
+				}
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// handleCancelled cancels the tool call named by a notifications/cancelled.
+func (t *callTracker) handleCancelled(ctx context.Context, notification mcp.JSONRPCNotification) {
+	requestID, ok := notification.Params.AdditionalFields["requestId"]
+	if !ok {
+		return
+	}
+	key := callKey(ctx, requestID)
+	t.mu.Lock()
+	cancel, ok := t.cancels[key]
+	t.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// callKey identifies a request within its client session. Request IDs
+// reach both the hook and the notification handler as plain decoded JSON.
+func callKey(ctx context.Context, id any) string {
+	sessionID := ""
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		sessionID = session.SessionID()
+	}
+	return fmt.Sprintf("%s/%v", sessionID, normalizeID(id))
+}
+
+// normalizeID renders integral numeric IDs without a fractional part.
+func normalizeID(id any) any {
+	if f, ok := id.(float64); ok && f == float64(int64(f)) {
+		return int64(f)
+	}
+	return id
+}
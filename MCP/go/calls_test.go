@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/platform-api/mcp-server/config"
+)
+
+// post sends a JSON-RPC message to an MCP endpoint over streamable HTTP.
+func post(t *testing.T, url, baseURL, sessionID, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("API_BASE_URL", baseURL)
+	req.Header.Set("BASIC_AUTH", "a2V5Om5hbWU=")
+	if sessionID != "" {
+		req.Header.Set(server.HeaderKeySessionID, sessionID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestCancelOverStreamableHTTP(t *testing.T) {
+	// Ably never answers: the call only ends when it is cancelled
+	requested := make(chan struct{})
+	ablySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requested)
+		<-r.Context().Done()
+	}))
+	defer ablySrv.Close()
+
+	cfg := &config.APIConfig{CallTimeout: time.Minute}
+	mcpSrv := httptest.NewServer(mcpHandler(cfg, "HTTP", newCallTracker()))
+	defer mcpSrv.Close()
+
+	resp := post(t, mcpSrv.URL, ablySrv.URL, "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	resp.Body.Close()
+	sessionID := resp.Header.Get(server.HeaderKeySessionID)
+	if sessionID == "" {
+		t.Fatal("initialize returned no session ID")
+	}
+
+	type result struct {
+		Result struct {
+			IsError bool `json:"isError"`
+		} `json:"result"`
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp := post(t, mcpSrv.URL, ablySrv.URL, sessionID, `{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"get_channels_channel_id","arguments":{"channel_id":"slow"}}}`)
+		defer resp.Body.Close()
+		var r result
+		json.NewDecoder(resp.Body).Decode(&r)
+		done <- r
+	}()
+
+	select {
+	case <-requested:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the tool call never reached Ably")
+	}
+	resp = post(t, mcpSrv.URL, ablySrv.URL, sessionID, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7}}`)
+	resp.Body.Close()
+
+	select {
+	case r := <-done:
+		if !r.Result.IsError {
+			t.Error("cancelled call did not return an error result")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the tool call was not cancelled")
+	}
+}
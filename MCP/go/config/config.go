@@ -1,16 +1,110 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type APIConfig struct {
 	BaseURL     string
-	BearerToken string // For OAuth2/Bearer authentication
-	APIKey      string // For API key authentication
-	BasicAuth   string // For basic authentication
-	Port        string // For server port configuration
+	BearerToken string        // For Ably Token/JWT authentication; takes precedence over APIKey and BasicAuth
+	APIKey      string        // For API key authentication ("keyName:keySecret"); takes precedence over BasicAuth
+	BasicAuth   string        // For basic authentication (base64 encoded "keyName:keySecret")
+	Port        string        // For server port configuration
+	TokenTTL    time.Duration // Lifetime of tokens minted from APIKey; zero uses the client default
+
+	MaxRetries     int           // Retries of a failed idempotent request; zero disables retries
+	RetryBackoff   time.Duration // Base delay between retries, doubled on each attempt
+	FallbackHosts  []string      // Alternate hosts tried when the BaseURL host fails
+	AttemptTimeout time.Duration // Deadline for a single attempt at a request; zero disables it
+
+	CallTimeout  time.Duration            // Deadline for a whole tool call, including retries; zero disables it
+	ToolTimeouts map[string]time.Duration // Per-tool overrides of CallTimeout, keyed by tool name
+
+	CipherKeys map[string][]byte // AES keys of encrypted channels, keyed by channel name
+
+	Format     string // Wire format of request and response bodies, FormatJSON or FormatMsgpack
+	APIVersion string // Ably protocol version, sent as X-Ably-Version on every request
+
+	ClockSkewCheck bool // Measure the offset of the local clock from Ably's at startup and correct timestamps by it
+}
+
+// Defaults for settings whose environment variable is not set.
+const (
+	DefaultMaxRetries     = 3
+	DefaultRetryBackoff   = 250 * time.Millisecond
+	DefaultAttemptTimeout = 15 * time.Second
+	DefaultCallTimeout    = 60 * time.Second
+	DefaultAPIVersion     = "2"
+)
+
+// Wire formats for request and response bodies.
+const (
+	FormatJSON    = "json"
+	FormatMsgpack = "msgpack"
+)
+
+// TimeoutFor returns the deadline for a call of the named tool.
+func (c *APIConfig) TimeoutFor(tool string) time.Duration {
+	if timeout, ok := c.ToolTimeouts[tool]; ok {
+		return timeout
+	}
+	return c.CallTimeout
+}
+
+// WithEndpoint returns a copy of c using the given base URL and credentials
+// in place of its own, keeping all other settings.
+func (c *APIConfig) WithEndpoint(baseURL, bearerToken, apiKey, basicAuth string) *APIConfig {
+	cfg := *c
+	cfg.BaseURL = baseURL
+	cfg.BearerToken = bearerToken
+	cfg.APIKey = apiKey
+	cfg.BasicAuth = basicAuth
+	return &cfg
+}
+
+// WithCipherKeys returns a copy of c that also knows the given channel
+// cipher keys, which replace any of its own for the same channels.
+func (c *APIConfig) WithCipherKeys(keys map[string][]byte) *APIConfig {
+	cfg := *c
+	cfg.CipherKeys = make(map[string][]byte, len(c.CipherKeys)+len(keys))
+	for channel, key := range c.CipherKeys {
+		cfg.CipherKeys[channel] = key
+	}
+	for channel, key := range keys {
+		cfg.CipherKeys[channel] = key
+	}
+	return &cfg
+}
+
+// ParseCipherKeys parses comma-separated channel=key pairs, where each key
+// is a base64 encoded 128 or 256 bit AES key.
+func ParseCipherKeys(s string) (map[string][]byte, error) {
+	keys := make(map[string][]byte)
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		// Channel names may contain "=", base64 keys only as padding
+		i := strings.LastIndex(strings.TrimRight(pair, "="), "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid cipher key entry for %q: expected channel=key", pair)
+		}
+		channel := strings.TrimSpace(pair[:i])
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(pair[i+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cipher key for channel %q: %w", channel, err)
+		}
+		if len(key) != 16 && len(key) != 32 {
+			return nil, fmt.Errorf("invalid cipher key for channel %q: must be 128 or 256 bits, got %d", channel, len(key)*8)
+		}
+		keys[channel] = key
+	}
+	return keys, nil
 }
 
 func LoadAPIConfig() (*APIConfig, error) {
@@ -19,20 +113,119 @@ func LoadAPIConfig() (*APIConfig, error) {
 	if port == "" {
 		port = os.Getenv("port")
 	}
-	
+
 	baseURL := os.Getenv("API_BASE_URL")
-	
+
+	var tokenTTL time.Duration
+	if v := os.Getenv("TOKEN_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TOKEN_TTL %q: %w", v, err)
+		}
+		tokenTTL = ttl
+	}
+
+	maxRetries := DefaultMaxRetries
+	if v := os.Getenv("MAX_RETRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid MAX_RETRIES %q: must be a non-negative integer", v)
+		}
+		maxRetries = n
+	}
+
+	retryBackoff := DefaultRetryBackoff
+	if v := os.Getenv("RETRY_BACKOFF"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RETRY_BACKOFF %q: %w", v, err)
+		}
+		if d < 0 {
+			return nil, fmt.Errorf("invalid RETRY_BACKOFF %q: must not be negative", v)
+		}
+		retryBackoff = d
+	}
+
+	var fallbackHosts []string
+	for _, host := range strings.Split(os.Getenv("FALLBACK_HOSTS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			fallbackHosts = append(fallbackHosts, host)
+		}
+	}
+
+	attemptTimeout := DefaultAttemptTimeout
+	if v := os.Getenv("ATTEMPT_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ATTEMPT_TIMEOUT %q: %w", v, err)
+		}
+		attemptTimeout = d
+	}
+
+	callTimeout := DefaultCallTimeout
+	if v := os.Getenv("CALL_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CALL_TIMEOUT %q: %w", v, err)
+		}
+		callTimeout = d
+	}
+
+	// TOOL_TIMEOUTS holds comma-separated tool=duration pairs
+	toolTimeouts := make(map[string]time.Duration)
+	for _, pair := range strings.Split(os.Getenv("TOOL_TIMEOUTS"), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		tool, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid TOOL_TIMEOUTS entry %q: expected tool=duration", pair)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid TOOL_TIMEOUTS entry %q: %w", pair, err)
+		}
+		toolTimeouts[strings.TrimSpace(tool)] = d
+	}
+
+	cipherKeys, err := ParseCipherKeys(os.Getenv("CIPHER_KEYS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIPHER_KEYS: %w", err)
+	}
+
+	format := FormatJSON
+	if v := os.Getenv("WIRE_FORMAT"); v != "" {
+		if v != FormatJSON && v != FormatMsgpack {
+			return nil, fmt.Errorf("invalid WIRE_FORMAT %q: must be %s or %s", v, FormatJSON, FormatMsgpack)
+		}
+		format = v
+	}
+
+	apiVersion := DefaultAPIVersion
+	if v := os.Getenv("ABLY_VERSION"); v != "" {
+		apiVersion = v
+	}
+
+	var clockSkewCheck bool
+	if v := os.Getenv("CLOCK_SKEW_CHECK"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CLOCK_SKEW_CHECK %q: must be true or false", v)
+		}
+		clockSkewCheck = b
+	}
+
 	// Check transport environment variable (both uppercase and lowercase)
 	transport := os.Getenv("TRANSPORT")
 	if transport == "" {
 		transport = os.Getenv("transport")
 	}
-	
+
 	// For STDIO mode (transport is not "http"/"HTTP"/"https"/"HTTPS"), API_BASE_URL is required from environment
 	if transport != "http" && transport != "HTTP" && transport != "https" && transport != "HTTPS" && baseURL == "" {
 		return nil, fmt.Errorf("API_BASE_URL environment variable not set")
 	}
-	
+
 	// For HTTP/HTTPS mode (transport is "http"/"HTTP"/"https"/"HTTPS"), API_BASE_URL comes from headers
 	// so we don't require it from environment variables
 
@@ -42,7 +235,21 @@ func LoadAPIConfig() (*APIConfig, error) {
 		APIKey:      os.Getenv("API_KEY"),
 		BasicAuth:   os.Getenv("BASIC_AUTH"),
 		Port:        port,
-	}, nil
-}
+		TokenTTL:    tokenTTL,
+
+		MaxRetries:     maxRetries,
+		RetryBackoff:   retryBackoff,
+		FallbackHosts:  fallbackHosts,
+		AttemptTimeout: attemptTimeout,
+
+		CallTimeout:  callTimeout,
+		ToolTimeouts: toolTimeouts,
 
+		CipherKeys: cipherKeys,
 
+		Format:     format,
+		APIVersion: apiVersion,
+
+		ClockSkewCheck: clockSkewCheck,
+	}, nil
+}
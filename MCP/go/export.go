@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/platform-api/mcp-server/ably"
+	"github.com/platform-api/mcp-server/config"
+	"github.com/platform-api/mcp-server/stats"
+)
+
+// exportStats serves the export of the export_stats tool over HTTP, so that
+// dashboards and spreadsheets can pull stats without an MCP client. The
+// query parameters are those of the tool, with metrics separated by commas;
+// the endpoint and credentials come from the headers, as for /mcp.
+func exportStats(cfg *config.APIConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		apiCfg, err := requestConfig(cfg, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		args := make(map[string]any)
+		query := r.URL.Query()
+		for _, name := range []string{"format", "unit", "start", "end"} {
+			if query.Has(name) {
+				args[name] = query.Get(name)
+			}
+		}
+		if query.Has("metrics") {
+			var metrics []any
+			for _, v := range query["metrics"] {
+				for _, name := range strings.Split(v, ",") {
+					metrics = append(metrics, strings.TrimSpace(name))
+				}
+			}
+			args["metrics"] = metrics
+		}
+		export, err := stats.ExportArgs(args, ably.Now(apiCfg.BaseURL))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		if timeout := apiCfg.TimeoutFor("export_stats"); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		w.Header().Set("Content-Type", stats.ContentTypes[export.Format])
+		if err := export.Run(ctx, ably.NewClient(apiCfg), w); err != nil {
+			log.Printf("Stats export failed: %v", err)
+			w.Header().Del("Content-Type")
+			http.Error(w, err.Error(), exportStatus(err))
+		}
+	}
+}
+
+// exportStatus returns the HTTP status reporting a failed export: that of
+// Ably's response for errors in the request or its credentials, and 502 or
+// 504 when Ably failed or did not answer in time.
+func exportStatus(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	var apiErr *ably.Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode < 500 {
+		return apiErr.StatusCode
+	}
+	return http.StatusBadGateway
+}
@@ -2,23 +2,33 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/platform-api/mcp-server/ably"
 	"github.com/platform-api/mcp-server/config"
 )
 
+// clockCheckTimeout bounds the clock skew check at startup.
+const clockCheckTimeout = 10 * time.Second
+
 func main() {
 	cfg, err := config.LoadAPIConfig()
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	if cfg.ClockSkewCheck {
+		checkClockSkew(cfg)
+	}
 
 	// Check transport environment variable (both uppercase and lowercase)
 	transport := os.Getenv("TRANSPORT")
@@ -46,32 +56,9 @@ func main() {
 		log.Printf("Running in %s mode on port %s", transport, port)
 
 		mux := http.NewServeMux()
-		mux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
-			// Read headers for dynamic config
-			apiCfg := &config.APIConfig{
-				BaseURL:     r.Header.Get("API_BASE_URL"),
-				BearerToken: r.Header.Get("BEARER_TOKEN"),
-				APIKey:      r.Header.Get("API_KEY"),
-				BasicAuth:   r.Header.Get("BASIC_AUTH"),
-			}
-
-			if apiCfg.BaseURL == "" {
-				http.Error(w, "Missing API_BASE_URL header", http.StatusBadRequest)
-				return
-			}
+		mux.HandleFunc("/mcp", mcpHandler(cfg, transport, newCallTracker()))
 
-			log.Printf("Incoming HTTP request - BaseURL: %s", apiCfg.BaseURL)
-
-			// Create MCP server for this request
-			mcpSrv := createMCPServer(apiCfg, transport)
-			handler := server.NewStreamableHTTPServer(mcpSrv, server.WithHTTPContextFunc(
-				func(ctx context.Context, req *http.Request) context.Context {
-					return context.WithValue(ctx, "apiConfig", apiCfg)
-				},
-			))
-
-			handler.ServeHTTP(w, r)
-		})
+		mux.HandleFunc("/stats/export", exportStats(cfg))
 
 		mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
 			w.Header().Set("Content-Type", "application/json")
@@ -118,7 +105,7 @@ func main() {
 
 	// STDIO Mode - default when no transport or transport is "stdio"
 	log.Println("Running in STDIO mode")
-	mcp := createMCPServer(cfg, "STDIO")
+	mcp := createMCPServer(cfg, "STDIO", newCallTracker())
 	go func() {
 		if err := server.ServeStdio(mcp); err != nil {
 			log.Fatalf("STDIO error: %v", err)
@@ -128,11 +115,106 @@ func main() {
 	log.Println("Received shutdown signal. Exiting STDIO mode.")
 }
 
-func createMCPServer(cfg *config.APIConfig, mode string) *server.MCPServer {
+// checkClockSkew measures the offset of the local clock from Ably's and
+// corrects the timestamps sent to Ably by it. Without a base URL in the
+// environment, as is usual in HTTP mode, the first get_time call for each
+// endpoint does so.
+func checkClockSkew(cfg *config.APIConfig) {
+	if cfg.BaseURL == "" {
+		log.Println("Clock skew check deferred: API_BASE_URL is not set, call get_time to measure the offset")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), clockCheckTimeout)
+	defer cancel()
+	sample, err := ably.NewClient(cfg).MeasureClock(ctx)
+	if err != nil {
+		log.Printf("Clock skew check failed, timestamps are not corrected: %v", err)
+		return
+	}
+	ably.SetClockOffset(cfg.BaseURL, sample.Offset)
+	log.Printf("Local clock offset from Ably: %v (round trip %v)", sample.Offset.Round(time.Millisecond), sample.RoundTrip.Round(time.Millisecond))
+}
+
+// requestConfig returns the configuration of an HTTP request: cfg with the
+// endpoint, credentials and session settings read from its headers.
+func requestConfig(cfg *config.APIConfig, r *http.Request) (*config.APIConfig, error) {
+	// Read headers for dynamic config. Server-wide settings such as
+	// retries come from the environment; the endpoint and credentials
+	// only ever come from the request headers
+	apiCfg := cfg.WithEndpoint(
+		r.Header.Get("API_BASE_URL"),
+		r.Header.Get("BEARER_TOKEN"),
+		r.Header.Get("API_KEY"),
+		r.Header.Get("BASIC_AUTH"),
+	)
+
+	// Fall back to a standard Authorization header so agents can
+	// forward their Ably Token or JWT without a custom header. Only do so
+	// when no credentials were given: the header may then be meant for a
+	// proxy or gateway in front of this server and must not reach Ably
+	if apiCfg.BearerToken == "" && apiCfg.APIKey == "" && apiCfg.BasicAuth == "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			apiCfg.BearerToken = token
+		}
+	}
+
+	// The protocol version can be pinned per session
+	if v := r.Header.Get("ABLY_VERSION"); v != "" {
+		apiCfg.APIVersion = v
+	}
+
+	// Cipher keys for encrypted channels can be supplied per session
+	// in addition to those configured for the whole server
+	if v := r.Header.Get("CIPHER_KEYS"); v != "" {
+		keys, err := config.ParseCipherKeys(v)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid CIPHER_KEYS header: %v", err)
+		}
+		apiCfg = apiCfg.WithCipherKeys(keys)
+	}
+
+	if apiCfg.BaseURL == "" {
+		return nil, errors.New("Missing API_BASE_URL header")
+	}
+	return apiCfg, nil
+}
+
+// mcpHandler serves MCP over streamable HTTP with a server created for each
+// request from its headers. The servers share calls, so that a cancellation
+// reaches the call it names whichever request carried it.
+func mcpHandler(cfg *config.APIConfig, transport string, calls *callTracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		apiCfg, err := requestConfig(cfg, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		log.Printf("Incoming HTTP request - BaseURL: %s", apiCfg.BaseURL)
+
+		// Create MCP server for this request
+		mcpSrv := createMCPServer(apiCfg, transport, calls)
+		handler := server.NewStreamableHTTPServer(mcpSrv, server.WithHTTPContextFunc(
+			func(ctx context.Context, req *http.Request) context.Context {
+				return context.WithValue(ctx, "apiConfig", apiCfg)
+			},
+		))
+
+		handler.ServeHTTP(w, r)
+	}
+}
+
+func createMCPServer(cfg *config.APIConfig, mode string, calls *callTracker) *server.MCPServer {
+	hooks := &server.Hooks{}
+	hooks.AddBeforeCallTool(calls.tagCall)
+
 	mcp := server.NewMCPServer("Platform API", "1.1.0",
 		server.WithToolCapabilities(true),
 		server.WithRecovery(),
+		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(calls.middleware(cfg)),
 	)
+	mcp.AddNotificationHandler("notifications/cancelled", calls.handleCancelled)
 
 	tools := GetAll(cfg)
 	log.Printf("Loaded %d tools for %s mode", len(tools), mode)
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/platform-api/mcp-server/config"
+)
+
+func TestRequestConfigAuthorizationFallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		bearer  string
+		apiKey  string
+	}{
+		{
+			name:    "only Authorization",
+			headers: map[string]string{"Authorization": "Bearer ably-token"},
+			bearer:  "ably-token",
+		},
+		{
+			name:    "BEARER_TOKEN wins",
+			headers: map[string]string{"BEARER_TOKEN": "explicit", "Authorization": "Bearer proxy-token"},
+			bearer:  "explicit",
+		},
+		{
+			name:    "API_KEY keeps a proxy token from Ably",
+			headers: map[string]string{"API_KEY": "app.key:secret", "Authorization": "Bearer proxy-token"},
+			apiKey:  "app.key:secret",
+		},
+		{
+			name:    "BASIC_AUTH keeps a proxy token from Ably",
+			headers: map[string]string{"BASIC_AUTH": "a2V5OnNlY3JldA==", "Authorization": "Bearer proxy-token"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/mcp", nil)
+			r.Header.Set("API_BASE_URL", "https://rest.ably.io")
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			cfg, err := requestConfig(&config.APIConfig{}, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.BearerToken != tt.bearer || cfg.APIKey != tt.apiKey {
+				t.Errorf("bearer %q, API key %q; want %q, %q", cfg.BearerToken, cfg.APIKey, tt.bearer, tt.apiKey)
+			}
+		})
+	}
+}
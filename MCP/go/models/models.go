@@ -13,7 +13,7 @@ type Tool struct {
 // ChannelStatus represents the ChannelStatus schema from the OpenAPI specification
 type ChannelStatus struct {
 	Isactive bool `json:"isActive"` // A required boolean value indicating whether the channel that is the subject of the event is active. For events indicating regional activity of a channel this indicates activity in that region, not global activity.
-	Occupancy Occupancy `json:"occupancy,omitempty"` // An Occupancy instance indicating the occupancy of a channel. For events indicating regional activity of a channel this indicates activity in that region, not global activity.
+	Occupancy Occupancy `json:"occupancy,omitzero"` // An Occupancy instance indicating the occupancy of a channel. For events indicating regional activity of a channel this indicates activity in that region, not global activity.
 }
 
 // DeviceDetails represents the DeviceDetails schema from the OpenAPI specification
@@ -22,7 +22,7 @@ type DeviceDetails struct {
 	Id string `json:"id,omitempty"` // Unique identifier for the device generated by the device itself.
 	Metadata map[string]interface{} `json:"metadata,omitempty"` // Optional metadata object for this device. The metadata for a device may only be set by clients with push-admin privileges and will be used more extensively in the future with smart notifications.
 	Platform string `json:"platform,omitempty"` // Platform of the push device.
-	Push_recipient Recipient `json:"push.recipient,omitempty"` // Push recipient details for a device.
+	Push_recipient Recipient `json:"push.recipient,omitzero"` // Push recipient details for a device.
 	Push_state string `json:"push.state,omitempty"` // the current state of the push device.
 	Clientid string `json:"clientId,omitempty"` // Optional trusted client identifier for the device.
 	Devicesecret string `json:"deviceSecret,omitempty"` // Secret value for the device.
@@ -30,6 +30,7 @@ type DeviceDetails struct {
 
 // Occupancy represents the Occupancy schema from the OpenAPI specification
 type Occupancy struct {
+	Connections int `json:"connections,omitempty"` // The number of connections attached to the channel.
 	Presencemembers int `json:"presenceMembers,omitempty"` // The number of members currently entered into the presence channel.
 	Presencesubscribers int `json:"presenceSubscribers,omitempty"` // The number of connections that are authorised to subscribe to presence messages.
 	Publishers int `json:"publishers,omitempty"` // The number of connections attached to the channel that are authorised to publish.
@@ -49,23 +50,23 @@ type Error struct {
 // Message represents the Message schema from the OpenAPI specification
 type Message struct {
 	Encoding string `json:"encoding,omitempty"` // This will typically be empty as all messages received from Ably are automatically decoded client-side using this value. However, if the message encoding cannot be processed, this attribute will contain the remaining transformations not applied to the data payload.
-	Extras Extras `json:"extras,omitempty"` // Extras object. Currently only allows for [push](https://www.ably.io/documentation/general/push/publish#channel-broadcast-example) extra.
+	Extras Extras `json:"extras,omitzero"` // Extras object. Currently only allows for [push](https://www.ably.io/documentation/general/push/publish#channel-broadcast-example) extra.
 	Id string `json:"id,omitempty"` // A Unique ID that can be specified by the publisher for [idempotent publishing](https://www.ably.io/documentation/rest/messages#idempotent).
 	Name string `json:"name,omitempty"` // The event name, if provided.
 	Timestamp int64 `json:"timestamp,omitempty"` // Timestamp when the message was received by the Ably, as milliseconds since the epoch.
 	Clientid string `json:"clientId,omitempty"` // The [client ID](https://www.ably.io/documentation/core-features/authentication#identified-clients) of the publisher of this message.
 	Connectionid string `json:"connectionId,omitempty"` // The connection ID of the publisher of this message.
-	Data string `json:"data,omitempty"` // The string encoded payload, with the encoding specified below.
+	Data interface{} `json:"data,omitempty"` // The payload: a string, a JSON value, or a string encoded with the encoding specified below.
 }
 
 // PresenceMessage represents the PresenceMessage schema from the OpenAPI specification
 type PresenceMessage struct {
-	Action string `json:"action,omitempty"` // The event signified by a PresenceMessage.
+	Action PresenceAction `json:"action,omitempty"` // The event signified by a PresenceMessage.
 	Clientid string `json:"clientId,omitempty"` // The client ID of the publisher of this presence update.
 	Connectionid string `json:"connectionId,omitempty"` // The connection ID of the publisher of this presence update.
-	Data string `json:"data,omitempty"` // The presence update payload, if provided.
+	Data interface{} `json:"data,omitempty"` // The presence update payload, if provided: a string, a JSON value, or a string encoded with the encoding specified below.
 	Encoding string `json:"encoding,omitempty"` // This will typically be empty as all presence updates received from Ably are automatically decoded client-side using this value. However, if the message encoding cannot be processed, this attribute will contain the remaining transformations not applied to the data payload.
-	Extras Extras `json:"extras,omitempty"` // Extras object. Currently only allows for [push](https://www.ably.io/documentation/general/push/publish#channel-broadcast-example) extra.
+	Extras Extras `json:"extras,omitzero"` // Extras object. Currently only allows for [push](https://www.ably.io/documentation/general/push/publish#channel-broadcast-example) extra.
 	Id string `json:"id,omitempty"` // Unique ID assigned by Ably to this presence update.
 	Timestamp int64 `json:"timestamp,omitempty"` // Timestamp when the presence update was received by Ably, as milliseconds since the epoch.
 }
@@ -86,26 +87,27 @@ type TokenRequest struct {
 	Capability map[string]interface{} `json:"capability"` // The [capabilities](https://www.ably.io/documentation/core-features/authentication#capabilities-explained) (i.e. a set of channel names/namespaces and, for each, a set of operations) which should be a subset of the set of capabilities associated with the key specified in keyName.
 	Clientid string `json:"clientId,omitempty"` // The [client ID](https://www.ably.io/documentation/core-features/authentication#identified-clients) to be assosciated with the token. Can be set to * to allow for any client ID to be used.
 	Keyname string `json:"keyName"` // Name of the key used for the TokenRequest. The keyName comprises of the app ID and key ID on an API Key.
+	Ttl int64 `json:"ttl,omitempty"` // Requested time to live for the token in milliseconds. Defaults to 60 minutes when omitted.
 }
 
 // ChannelDetails represents the ChannelDetails schema from the OpenAPI specification
 type ChannelDetails struct {
 	Region string `json:"region,omitempty"` // In events relating to the activity of a channel in a specific region, this optionally identifies the region.
-	Status ChannelStatus `json:"status,omitempty"` // A ChannelStatus instance.
+	Status ChannelStatus `json:"status,omitzero"` // A ChannelStatus instance.
 	Channelid string `json:"channelId"` // The required name of the channel including any qualifier, if any.
 	Isglobalmaster bool `json:"isGlobalMaster,omitempty"` // In events relating to the activity of a channel in a specific region, this optionally identifies whether or not that region is responsible for global coordination of the channel.
 }
 
 // Extras represents the Extras schema from the OpenAPI specification
 type Extras struct {
-	Push Push `json:"push,omitempty"`
+	Push Push `json:"push,omitzero"`
 }
 
 // Push represents the Push schema from the OpenAPI specification
 type Push struct {
 	Data string `json:"data,omitempty"` // Arbitrary [key-value string-to-string payload](https://www.ably.io/documentation/general/push/publish#channel-broadcast-example).
 	Fcm map[string]interface{} `json:"fcm,omitempty"` // Extends and overrides generic values when delivering via GCM/FCM. [See examples](https://www.ably.io/documentation/general/push/publish#payload-structure)
-	Notification Notification `json:"notification,omitempty"`
+	Notification Notification `json:"notification,omitzero"`
 	Web map[string]interface{} `json:"web,omitempty"` // Extends and overrides generic values when delivering via web. [See examples](https://www.ably.io/documentation/general/push/publish#payload-structure)
 	Apns map[string]interface{} `json:"apns,omitempty"` // Extends and overrides generic values when delivering via APNs. [See examples](https://www.ably.io/documentation/general/push/publish#payload-structure)
 }
@@ -126,6 +128,7 @@ type SignedTokenRequest struct {
 	Timestamp int `json:"timestamp"` // Time of creation of the Ably TokenRequest.
 	Capability map[string]interface{} `json:"capability"` // The [capabilities](https://www.ably.io/documentation/core-features/authentication#capabilities-explained) (i.e. a set of channel names/namespaces and, for each, a set of operations) which should be a subset of the set of capabilities associated with the key specified in keyName.
 	Clientid string `json:"clientId,omitempty"` // The [client ID](https://www.ably.io/documentation/core-features/authentication#identified-clients) to be assosciated with the token. Can be set to * to allow for any client ID to be used.
+	Ttl int64 `json:"ttl,omitempty"` // Requested time to live for the token in milliseconds. Defaults to 60 minutes when omitted.
 	Mac string `json:"mac"` // A signature, generated as an HMAC of each of the above components, using the key secret value.
 }
 
@@ -137,3 +140,96 @@ type Notification struct {
 	Title string `json:"title,omitempty"` // Title to display at the notification.
 	Body string `json:"body,omitempty"` // Text below title on the expanded notification.
 }
+
+// PublishResponse represents the response of publishing messages to a channel
+type PublishResponse struct {
+	Channel string `json:"channel,omitempty"` // The name of the channel the messages were published to.
+	Messageid string `json:"messageId,omitempty"` // The ID assigned to the published message(s).
+}
+
+// ChannelSubscription represents a push channel subscription of a device or client
+type ChannelSubscription struct {
+	Channel string `json:"channel"` // Channel name.
+	Deviceid string `json:"deviceId,omitempty"` // Must be set when clientId is empty, cannot be used with clientId.
+	Clientid string `json:"clientId,omitempty"` // Must be set when deviceId is empty, cannot be used with deviceId.
+}
+
+// Stats represents the application statistics of one interval
+type Stats struct {
+	Intervalid string `json:"intervalId,omitempty"` // The interval the statistics are for, e.g. 2024-05-01:12:30 for a minute.
+	Unit string `json:"unit,omitempty"` // The length of the interval: minute, hour, day or month.
+	All MessageTypes `json:"all,omitzero"` // All messages, inbound and outbound.
+	Inbound MessageTraffic `json:"inbound,omitzero"` // Messages received by Ably, by transport.
+	Outbound MessageTraffic `json:"outbound,omitzero"` // Messages delivered by Ably, by transport.
+	Persisted MessageTypes `json:"persisted,omitzero"` // Messages persisted for history.
+	Connections ConnectionTypes `json:"connections,omitzero"` // Realtime connections.
+	Channels ResourceCount `json:"channels,omitzero"` // Active channels.
+	Apirequests RequestCount `json:"apiRequests,omitzero"` // REST API requests.
+	Tokenrequests RequestCount `json:"tokenRequests,omitzero"` // Token requests.
+	Push PushStats `json:"push,omitzero"` // Push notifications.
+}
+
+// MessageCount represents the number and size of messages
+type MessageCount struct {
+	Count float64 `json:"count,omitempty"` // The number of messages.
+	Data float64 `json:"data,omitempty"` // The total size of the messages, in bytes.
+	Failed float64 `json:"failed,omitempty"` // The number of messages that failed.
+	Refused float64 `json:"refused,omitempty"` // The number of messages refused, e.g. for exceeding a limit.
+}
+
+// MessageTypes represents message counts broken down by type
+type MessageTypes struct {
+	All MessageCount `json:"all,omitzero"` // Messages of all types.
+	Messages MessageCount `json:"messages,omitzero"` // Channel messages.
+	Presence MessageCount `json:"presence,omitzero"` // Presence messages.
+}
+
+// MessageTraffic represents message counts broken down by transport
+type MessageTraffic struct {
+	All MessageTypes `json:"all,omitzero"` // Messages over all transports.
+	Realtime MessageTypes `json:"realtime,omitzero"` // Messages over realtime connections.
+	Rest MessageTypes `json:"rest,omitzero"` // Messages over the REST API.
+	Webhook MessageTypes `json:"webhook,omitzero"` // Messages delivered by webhooks.
+	Push MessageTypes `json:"push,omitzero"` // Messages delivered by push.
+	Externalqueue MessageTypes `json:"externalQueue,omitzero"` // Messages delivered to external queues.
+	Sharedqueue MessageTypes `json:"sharedQueue,omitzero"` // Messages delivered to Ably queues.
+	Httpevent MessageTypes `json:"httpEvent,omitzero"` // Messages delivered by HTTP event integrations.
+}
+
+// ResourceCount represents the usage of a resource such as connections
+type ResourceCount struct {
+	Opened float64 `json:"opened,omitempty"` // The number of resources opened.
+	Peak float64 `json:"peak,omitempty"` // The peak number of resources in use.
+	Mean float64 `json:"mean,omitempty"` // The mean number of resources in use.
+	Min float64 `json:"min,omitempty"` // The minimum number of resources in use.
+	Refused float64 `json:"refused,omitempty"` // The number of resources refused, e.g. for exceeding a limit.
+}
+
+// ConnectionTypes represents connection counts broken down by security
+type ConnectionTypes struct {
+	All ResourceCount `json:"all,omitzero"` // All connections.
+	Plain ResourceCount `json:"plain,omitzero"` // Connections without TLS.
+	Tls ResourceCount `json:"tls,omitzero"` // Connections with TLS.
+}
+
+// RequestCount represents the outcome of requests
+type RequestCount struct {
+	Succeeded float64 `json:"succeeded,omitempty"` // The number of requests that succeeded.
+	Failed float64 `json:"failed,omitempty"` // The number of requests that failed.
+	Refused float64 `json:"refused,omitempty"` // The number of requests refused, e.g. for exceeding a limit.
+}
+
+// PushStats represents push notification counts
+type PushStats struct {
+	Messages float64 `json:"messages,omitempty"` // The number of push messages published.
+	Notifications PushNotifications `json:"notifications,omitzero"` // Delivery of push notifications.
+	Directpublishes float64 `json:"directPublishes,omitempty"` // The number of push notifications published directly to devices.
+}
+
+// PushNotifications represents the delivery of push notifications
+type PushNotifications struct {
+	Invalid float64 `json:"invalid,omitempty"` // Notifications that were invalid.
+	Attempted float64 `json:"attempted,omitempty"` // Notifications whose delivery was attempted.
+	Successful float64 `json:"successful,omitempty"` // Notifications delivered.
+	Failed float64 `json:"failed,omitempty"` // Notifications that failed to deliver.
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PresenceAction is the event signified by a PresenceMessage, by name.
+type PresenceAction string
+
+// Presence actions.
+const (
+	PresenceAbsent  PresenceAction = "absent"
+	PresencePresent PresenceAction = "present"
+	PresenceEnter   PresenceAction = "enter"
+	PresenceLeave   PresenceAction = "leave"
+	PresenceUpdate  PresenceAction = "update"
+)
+
+// presenceActions holds the actions by the number Ably sends them as.
+var presenceActions = []PresenceAction{PresenceAbsent, PresencePresent, PresenceEnter, PresenceLeave, PresenceUpdate}
+
+// UnmarshalJSON decodes an action given by number, as the Ably REST API
+// sends it, or by name.
+func (a *PresenceAction) UnmarshalJSON(b []byte) error {
+	var n int
+	if err := json.Unmarshal(b, &n); err == nil {
+		if n < 0 || n >= len(presenceActions) {
+			return fmt.Errorf("unknown presence action %d", n)
+		}
+		*a = presenceActions[n]
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("presence action must be a number or a string: %w", err)
+	}
+	*a = PresenceAction(s)
+	return nil
+}
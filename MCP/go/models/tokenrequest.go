@@ -0,0 +1,110 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// CapabilityText returns the JSON text of a capability as it is sent in a
+// TokenRequest and covered by its signature. encoding/json sorts map keys,
+// so the text is canonical. A nil capability has no text.
+func CapabilityText(capability map[string]interface{}) (string, error) {
+	if capability == nil {
+		return "", nil
+	}
+	b, err := json.Marshal(capability)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode capability: %w", err)
+	}
+	return string(b), nil
+}
+
+// parseCapability decodes a capability given as a JSON object or, as Ably
+// sends and expects it, as a string holding one.
+func parseCapability(raw json.RawMessage) (map[string]interface{}, error) {
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+	if raw[0] == '"' {
+		var text string
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return nil, err
+		}
+		if text == "" {
+			return nil, nil
+		}
+		raw = json.RawMessage(text)
+	}
+	var capability map[string]interface{}
+	if err := json.Unmarshal(raw, &capability); err != nil {
+		return nil, fmt.Errorf("capability must be a JSON object: %w", err)
+	}
+	return capability, nil
+}
+
+// MarshalJSON encodes the capability as its text, which is how Ably
+// expects it in a TokenRequest.
+func (r TokenRequest) MarshalJSON() ([]byte, error) {
+	type plain TokenRequest
+	capability, err := CapabilityText(r.Capability)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		plain
+		Capability string `json:"capability,omitempty"`
+	}{plain(r), capability})
+}
+
+// UnmarshalJSON accepts the capability as an object or as its text.
+func (r *TokenRequest) UnmarshalJSON(b []byte) error {
+	type plain TokenRequest
+	var aux struct {
+		plain
+		Capability json.RawMessage `json:"capability"`
+	}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	capability, err := parseCapability(aux.Capability)
+	if err != nil {
+		return err
+	}
+	*r = TokenRequest(aux.plain)
+	r.Capability = capability
+	return nil
+}
+
+// MarshalJSON encodes the capability as its text, which must be exactly
+// the text the request was signed over.
+func (r SignedTokenRequest) MarshalJSON() ([]byte, error) {
+	type plain SignedTokenRequest
+	capability, err := CapabilityText(r.Capability)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		plain
+		Capability string `json:"capability,omitempty"`
+	}{plain(r), capability})
+}
+
+// UnmarshalJSON accepts the capability as an object or as its text.
+func (r *SignedTokenRequest) UnmarshalJSON(b []byte) error {
+	type plain SignedTokenRequest
+	var aux struct {
+		plain
+		Capability json.RawMessage `json:"capability"`
+	}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	capability, err := parseCapability(aux.Capability)
+	if err != nil {
+		return err
+	}
+	*r = SignedTokenRequest(aux.plain)
+	r.Capability = capability
+	return nil
+}
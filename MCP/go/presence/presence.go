@@ -0,0 +1,292 @@
+// Package presence reconstructs the membership of a channel from its
+// presence history. Events are replayed oldest first from the start of a
+// window: members who entered before the window are only seen once they
+// update or leave within it, and members who entered before it and stayed
+// silent throughout are not seen at all.
+package presence
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/platform-api/mcp-server/ably"
+	"github.com/platform-api/mcp-server/models"
+)
+
+// History returns the presence events of a channel from start to end,
+// oldest first, decoded with key. It fails when there are more than limit
+// events, as membership cannot be reconstructed from part of them.
+func History(ctx context.Context, c *ably.Client, channel string, key []byte, start, end time.Time, limit int) ([]models.PresenceMessage, error) {
+	query := url.Values{}
+	query.Set("direction", "forwards")
+	query.Set("start", strconv.FormatInt(start.UnixMilli(), 10))
+	query.Set("end", strconv.FormatInt(end.UnixMilli(), 10))
+	req := &ably.Request{
+		Method: http.MethodGet,
+		Path:   ably.Path("channels", channel, "presence", "history"),
+		Query:  query,
+	}
+	var events []models.PresenceMessage
+	for {
+		req.Query.Set("limit", strconv.Itoa(min(ably.MaxPageSize, limit+1-len(events))))
+		resp, err := c.Do(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		var page []models.PresenceMessage
+		if err := resp.Decode(&page); err != nil {
+			return nil, fmt.Errorf("failed to decode presence history: %w", err)
+		}
+		events = append(events, page...)
+		if len(events) > limit {
+			return nil, fmt.Errorf("the presence history from %s to %s holds more than %d events; shorten the window or raise the scan limit", start.UTC().Format(ably.TimeLayout), end.UTC().Format(ably.TimeLayout), limit)
+		}
+		cursor := resp.NextCursor()
+		if cursor == "" {
+			break
+		}
+		if err := req.ApplyCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range events {
+		ably.DecodePresenceMessage(&events[i], key)
+	}
+	// Ably pages forwards history oldest first; sorting guards against
+	// events of the same millisecond straddling pages out of order
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp < events[j].Timestamp })
+	return events, nil
+}
+
+// Member is a member of the presence set of a channel. Entered is unset
+// when the member entered before the replayed window.
+type Member struct {
+	ClientID     string `json:"clientId"`
+	ConnectionID string `json:"connectionId,omitempty"`
+	Data         any    `json:"data,omitempty"`
+	Entered      string `json:"entered,omitempty"`
+	Updated      string `json:"updated,omitempty"`
+}
+
+// memberKey identifies a member as Ably does: a client may be present once
+// per connection.
+func memberKey(e models.PresenceMessage) string {
+	return e.Connectionid + ":" + e.Clientid
+}
+
+// present reports whether action leaves its member in the presence set.
+func present(action models.PresenceAction) bool {
+	return action != models.PresenceLeave && action != models.PresenceAbsent
+}
+
+// Snapshot returns the members present at t, ordered by client ID and
+// connection ID, by replaying events up to and including t.
+func Snapshot(events []models.PresenceMessage, t time.Time) []Member {
+	members := replay(events, t)
+	snapshot := make([]Member, 0, len(members))
+	for _, m := range members {
+		snapshot = append(snapshot, *m)
+	}
+	sortMembers(snapshot)
+	return snapshot
+}
+
+func replay(events []models.PresenceMessage, t time.Time) map[string]*Member {
+	members := make(map[string]*Member)
+	for _, e := range events {
+		if e.Timestamp > t.UnixMilli() {
+			break
+		}
+		k := memberKey(e)
+		if !present(e.Action) {
+			delete(members, k)
+			continue
+		}
+		m, ok := members[k]
+		if !ok {
+			m = &Member{ClientID: e.Clientid, ConnectionID: e.Connectionid}
+			members[k] = m
+			if e.Action == models.PresenceEnter {
+				m.Entered = ably.FormatTimestamp(e.Timestamp)
+			}
+		}
+		m.Data = e.Data
+		if e.Action == models.PresenceUpdate {
+			m.Updated = ably.FormatTimestamp(e.Timestamp)
+		}
+	}
+	return members
+}
+
+// Change is the data of a member that updated it between two points in time.
+type Change struct {
+	ClientID     string `json:"clientId"`
+	ConnectionID string `json:"connectionId,omitempty"`
+	Before       any    `json:"before,omitempty"`
+	After        any    `json:"after,omitempty"`
+	Updates      int    `json:"updates"`
+}
+
+// Diff is how the presence set changed between two points in time.
+type Diff struct {
+	Joined  []Member `json:"joined"`
+	Left    []Member `json:"left"`
+	Updated []Change `json:"updated"`
+}
+
+// Compare diffs the presence sets at from and to: the members who joined,
+// those who left, and those present at both who updated their data in
+// between.
+func Compare(events []models.PresenceMessage, from, to time.Time) Diff {
+	before, after := replay(events, from), replay(events, to)
+	diff := Diff{Joined: []Member{}, Left: []Member{}, Updated: []Change{}}
+	for k, m := range after {
+		if _, ok := before[k]; !ok {
+			diff.Joined = append(diff.Joined, *m)
+		}
+	}
+	for k, m := range before {
+		if _, ok := after[k]; !ok {
+			diff.Left = append(diff.Left, *m)
+		}
+	}
+
+	updates := make(map[string]int)
+	for _, e := range events {
+		if e.Timestamp > from.UnixMilli() && e.Timestamp <= to.UnixMilli() && e.Action == models.PresenceUpdate {
+			updates[memberKey(e)]++
+		}
+	}
+	for k, n := range updates {
+		b, ok := before[k]
+		a, stayed := after[k]
+		if !ok || !stayed || reflect.DeepEqual(b.Data, a.Data) {
+			continue
+		}
+		diff.Updated = append(diff.Updated, Change{ClientID: a.ClientID, ConnectionID: a.ConnectionID, Before: b.Data, After: a.Data, Updates: n})
+	}
+
+	sortMembers(diff.Joined)
+	sortMembers(diff.Left)
+	sort.Slice(diff.Updated, func(i, j int) bool {
+		if diff.Updated[i].ClientID != diff.Updated[j].ClientID {
+			return diff.Updated[i].ClientID < diff.Updated[j].ClientID
+		}
+		return diff.Updated[i].ConnectionID < diff.Updated[j].ConnectionID
+	})
+	return diff
+}
+
+// Session is a stay of a member in the presence set. StartedBefore is set
+// when the member entered before the replayed window, which then bounds the
+// session, and Ongoing when the member had not left by its end.
+type Session struct {
+	ConnectionID  string `json:"connectionId,omitempty"`
+	Start         string `json:"start"`
+	End           string `json:"end"`
+	DurationMs    int64  `json:"duration_ms"`
+	Duration      string `json:"duration"`
+	StartedBefore bool   `json:"started_before_window,omitempty"`
+	Ongoing       bool   `json:"ongoing,omitempty"`
+}
+
+// ClientSessions are the sessions of a client, on any of its connections.
+type ClientSessions struct {
+	ClientID        string    `json:"clientId"`
+	Sessions        []Session `json:"sessions"`
+	TotalDurationMs int64     `json:"total_duration_ms"`
+	TotalDuration   string    `json:"total_duration"`
+}
+
+// Sessions returns the presence sessions of each client within the window
+// from start to end, clients with the longest total presence first.
+func Sessions(events []models.PresenceMessage, start, end time.Time) []ClientSessions {
+	type openSession struct {
+		start  int64
+		before bool
+	}
+	open := make(map[string]openSession)
+	byClient := make(map[string]*ClientSessions)
+	add := func(clientID, connectionID string, s openSession, endMs int64, ongoing bool) {
+		c, ok := byClient[clientID]
+		if !ok {
+			c = &ClientSessions{ClientID: clientID}
+			byClient[clientID] = c
+		}
+		d := endMs - s.start
+		c.Sessions = append(c.Sessions, Session{
+			ConnectionID:  connectionID,
+			Start:         ably.FormatTimestamp(s.start),
+			End:           ably.FormatTimestamp(endMs),
+			DurationMs:    d,
+			Duration:      formatDuration(d),
+			StartedBefore: s.before,
+			Ongoing:       ongoing,
+		})
+		c.TotalDurationMs += d
+	}
+
+	startMs, endMs := start.UnixMilli(), end.UnixMilli()
+	last := make(map[string]models.PresenceMessage)
+	for _, e := range events {
+		if e.Timestamp > endMs {
+			break
+		}
+		k := memberKey(e)
+		last[k] = e
+		s, isOpen := open[k]
+		switch {
+		case present(e.Action) && !isOpen:
+			// Only an enter starts a session; any other action shows the
+			// member was already present when the window started
+			if e.Action == models.PresenceEnter {
+				open[k] = openSession{start: e.Timestamp}
+			} else {
+				open[k] = openSession{start: startMs, before: true}
+			}
+		case !present(e.Action) && isOpen:
+			add(e.Clientid, e.Connectionid, s, e.Timestamp, false)
+			delete(open, k)
+		case !present(e.Action):
+			add(e.Clientid, e.Connectionid, openSession{start: startMs, before: true}, e.Timestamp, false)
+		}
+	}
+	for k, s := range open {
+		add(last[k].Clientid, last[k].Connectionid, s, endMs, true)
+	}
+
+	result := make([]ClientSessions, 0, len(byClient))
+	for _, c := range byClient {
+		sort.Slice(c.Sessions, func(i, j int) bool { return c.Sessions[i].Start < c.Sessions[j].Start })
+		c.TotalDuration = formatDuration(c.TotalDurationMs)
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalDurationMs != result[j].TotalDurationMs {
+			return result[i].TotalDurationMs > result[j].TotalDurationMs
+		}
+		return result[i].ClientID < result[j].ClientID
+	})
+	return result
+}
+
+// formatDuration renders a duration in milliseconds to the second.
+func formatDuration(ms int64) string {
+	return (time.Duration(ms) * time.Millisecond).Round(time.Second).String()
+}
+
+func sortMembers(members []Member) {
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].ClientID != members[j].ClientID {
+			return members[i].ClientID < members[j].ClientID
+		}
+		return members[i].ConnectionID < members[j].ConnectionID
+	})
+}
@@ -0,0 +1,197 @@
+package presence
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/platform-api/mcp-server/ably"
+	"github.com/platform-api/mcp-server/models"
+)
+
+var base = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// at returns the time s seconds into the test history.
+func at(s int) time.Time {
+	return base.Add(time.Duration(s) * time.Second)
+}
+
+func event(s int, action models.PresenceAction, clientID, connectionID string, data any) models.PresenceMessage {
+	return models.PresenceMessage{
+		Timestamp:    at(s).UnixMilli(),
+		Action:       action,
+		Clientid:     clientID,
+		Connectionid: connectionID,
+		Data:         data,
+	}
+}
+
+func stamp(s int) string {
+	return ably.FormatTimestamp(at(s).UnixMilli())
+}
+
+func clientIDs(members []Member) []string {
+	ids := []string{}
+	for _, m := range members {
+		ids = append(ids, m.ClientID+"@"+m.ConnectionID)
+	}
+	return ids
+}
+
+func TestSnapshotEnterLeave(t *testing.T) {
+	events := []models.PresenceMessage{
+		event(10, models.PresenceEnter, "alice", "c1", "hi"),
+		event(20, models.PresenceEnter, "bob", "c2", nil),
+		event(40, models.PresenceLeave, "alice", "c1", nil),
+	}
+	tests := []struct {
+		at   int
+		want []string
+	}{
+		{5, []string{}},
+		{10, []string{"alice@c1"}},
+		{30, []string{"alice@c1", "bob@c2"}},
+		{40, []string{"bob@c2"}},
+	}
+	for _, tt := range tests {
+		if got := clientIDs(Snapshot(events, at(tt.at))); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Snapshot at %ds = %v, want %v", tt.at, got, tt.want)
+		}
+	}
+	snapshot := Snapshot(events, at(30))
+	if want := (Member{ClientID: "alice", ConnectionID: "c1", Data: "hi", Entered: stamp(10)}); !reflect.DeepEqual(snapshot[0], want) {
+		t.Errorf("alice = %+v, want %+v", snapshot[0], want)
+	}
+}
+
+func TestCompareAcrossEnterLeave(t *testing.T) {
+	events := []models.PresenceMessage{
+		event(10, models.PresenceEnter, "alice", "c1", nil),
+		event(20, models.PresenceEnter, "bob", "c2", "idle"),
+		event(35, models.PresenceUpdate, "bob", "c2", "busy"),
+		event(36, models.PresenceUpdate, "bob", "c2", "away"),
+		event(40, models.PresenceLeave, "alice", "c1", nil),
+		event(45, models.PresenceEnter, "carol", "c3", nil),
+	}
+	diff := Compare(events, at(30), at(50))
+	if got := clientIDs(diff.Joined); !reflect.DeepEqual(got, []string{"carol@c3"}) {
+		t.Errorf("joined = %v, want carol", got)
+	}
+	if got := clientIDs(diff.Left); !reflect.DeepEqual(got, []string{"alice@c1"}) {
+		t.Errorf("left = %v, want alice", got)
+	}
+	want := []Change{{ClientID: "bob", ConnectionID: "c2", Before: "idle", After: "away", Updates: 2}}
+	if !reflect.DeepEqual(diff.Updated, want) {
+		t.Errorf("updated = %+v, want %+v", diff.Updated, want)
+	}
+
+	// A member who entered and left between the two points does not show
+	diff = Compare(events, at(5), at(42))
+	if got := clientIDs(diff.Joined); !reflect.DeepEqual(got, []string{"bob@c2"}) {
+		t.Errorf("joined = %v, want bob", got)
+	}
+	if len(diff.Left) != 0 || len(diff.Updated) != 0 {
+		t.Errorf("diff = %+v, want only bob joined", diff)
+	}
+}
+
+func TestMemberEnteredBeforeWindow(t *testing.T) {
+	events := []models.PresenceMessage{
+		event(15, models.PresenceUpdate, "dave", "c4", "typing"),
+		event(45, models.PresenceLeave, "dave", "c4", nil),
+	}
+	snapshot := Snapshot(events, at(20))
+	want := []Member{{ClientID: "dave", ConnectionID: "c4", Data: "typing", Updated: stamp(15)}}
+	if !reflect.DeepEqual(snapshot, want) {
+		t.Errorf("Snapshot = %+v, want %+v", snapshot, want)
+	}
+	// Before the update the member is not seen
+	if got := Snapshot(events, at(10)); len(got) != 0 {
+		t.Errorf("Snapshot before the update = %+v, want none", got)
+	}
+
+	sessions := Sessions(events, at(0), at(60))
+	wantSessions := []ClientSessions{{
+		ClientID: "dave",
+		Sessions: []Session{{
+			ConnectionID:  "c4",
+			Start:         stamp(0),
+			End:           stamp(45),
+			DurationMs:    45000,
+			Duration:      "45s",
+			StartedBefore: true,
+		}},
+		TotalDurationMs: 45000,
+		TotalDuration:   "45s",
+	}}
+	if !reflect.DeepEqual(sessions, wantSessions) {
+		t.Errorf("Sessions = %+v, want %+v", sessions, wantSessions)
+	}
+}
+
+func TestClientOnTwoConnections(t *testing.T) {
+	events := []models.PresenceMessage{
+		event(10, models.PresenceEnter, "erin", "c5", "phone"),
+		event(20, models.PresenceEnter, "erin", "c6", "laptop"),
+		event(30, models.PresenceLeave, "erin", "c5", nil),
+	}
+	if got := clientIDs(Snapshot(events, at(25))); !reflect.DeepEqual(got, []string{"erin@c5", "erin@c6"}) {
+		t.Errorf("Snapshot at 25s = %v, want both connections", got)
+	}
+	if got := clientIDs(Snapshot(events, at(35))); !reflect.DeepEqual(got, []string{"erin@c6"}) {
+		t.Errorf("Snapshot at 35s = %v, want the remaining connection", got)
+	}
+
+	sessions := Sessions(events, at(0), at(60))
+	if len(sessions) != 1 {
+		t.Fatalf("Sessions = %+v, want one client", sessions)
+	}
+	want := ClientSessions{
+		ClientID: "erin",
+		Sessions: []Session{
+			{ConnectionID: "c5", Start: stamp(10), End: stamp(30), DurationMs: 20000, Duration: "20s"},
+			{ConnectionID: "c6", Start: stamp(20), End: stamp(60), DurationMs: 40000, Duration: "40s", Ongoing: true},
+		},
+		TotalDurationMs: 60000,
+		TotalDuration:   "1m0s",
+	}
+	if !reflect.DeepEqual(sessions[0], want) {
+		t.Errorf("Sessions = %+v, want %+v", sessions[0], want)
+	}
+}
+
+func TestLeaveWithoutEnter(t *testing.T) {
+	events := []models.PresenceMessage{
+		event(10, models.PresenceEnter, "frank", "c7", nil),
+		event(25, models.PresenceLeave, "gina", "c8", nil),
+		event(50, models.PresenceLeave, "frank", "c7", nil),
+	}
+	if got := clientIDs(Snapshot(events, at(30))); !reflect.DeepEqual(got, []string{"frank@c7"}) {
+		t.Errorf("Snapshot = %v, want only frank", got)
+	}
+	if diff := Compare(events, at(20), at(30)); len(diff.Joined) != 0 || len(diff.Left) != 0 {
+		t.Errorf("Compare = %+v, want no change", diff)
+	}
+
+	// The leave closes a session that started before the window; frank's
+	// longer session sorts first
+	sessions := Sessions(events, at(0), at(60))
+	if len(sessions) != 2 || sessions[0].ClientID != "frank" || sessions[1].ClientID != "gina" {
+		t.Fatalf("Sessions = %+v, want frank then gina", sessions)
+	}
+	want := Session{ConnectionID: "c8", Start: stamp(0), End: stamp(25), DurationMs: 25000, Duration: "25s", StartedBefore: true}
+	if !reflect.DeepEqual(sessions[1].Sessions, []Session{want}) {
+		t.Errorf("gina's sessions = %+v, want %+v", sessions[1].Sessions, want)
+	}
+}
+
+func TestSessionsIgnoreEventsAfterEnd(t *testing.T) {
+	events := []models.PresenceMessage{
+		event(10, models.PresenceEnter, "hal", "c9", nil),
+		event(70, models.PresenceLeave, "hal", "c9", nil),
+	}
+	sessions := Sessions(events, at(0), at(60))
+	if len(sessions) != 1 || len(sessions[0].Sessions) != 1 || !sessions[0].Sessions[0].Ongoing || sessions[0].TotalDurationMs != 50000 {
+		t.Errorf("Sessions = %+v, want one ongoing session of 50s", sessions)
+	}
+}
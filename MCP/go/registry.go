@@ -20,16 +20,29 @@ func GetAll(cfg *config.APIConfig) []models.Tool {
 		tools_push.CreateGetregisteredpushdevicesTool(cfg),
 		tools_push.CreateRegisterpushdeviceTool(cfg),
 		tools_stats.CreateGetstatsTool(cfg),
+		tools_stats.CreateStatssummaryTool(cfg),
+		tools_stats.CreateStatscompareTool(cfg),
+		tools_stats.CreateStatspeaksTool(cfg),
+		tools_stats.CreateStatsanomaliesTool(cfg),
+		tools_stats.CreateExportstatsTool(cfg),
 		tools_history.CreateGetmessagesbychannelTool(cfg),
+		tools_history.CreateSearchchannelmessagesTool(cfg),
 		tools_publishing.CreatePublishmessagestochannelTool(cfg),
+		tools_publishing.CreateBatchpublishTool(cfg),
 		tools_push.CreateSubscribepushdevicetochannelTool(cfg),
 		tools_push.CreateDeletepushdevicedetailsTool(cfg),
 		tools_push.CreateGetpushsubscriptionsonchannelsTool(cfg),
 		tools_push.CreateGetchannelswithpushsubscribersTool(cfg),
 		tools_push.CreatePublishpushnotificationtodevicesTool(cfg),
 		tools_status.CreateGetmetadataofchannelTool(cfg),
+		tools_status.CreateChanneloccupancyTool(cfg),
+		tools_status.CreateGetdiagnosticsTool(cfg),
 		tools_history.CreateGetpresencehistoryofchannelTool(cfg),
+		tools_history.CreatePresencesnapshotTool(cfg),
 		tools_authentication.CreateRequestaccesstokenTool(cfg),
+		tools_authentication.CreateSigntokenrequestTool(cfg),
+		tools_authentication.CreateExplaincapabilityTool(cfg),
+		tools_authentication.CreateIntersectcapabilityTool(cfg),
 		tools_push.CreateUnregisterpushdeviceTool(cfg),
 		tools_push.CreateGetpushdevicedetailsTool(cfg),
 		tools_push.CreatePatchpushdevicedetailsTool(cfg),
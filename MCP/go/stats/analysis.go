@@ -0,0 +1,207 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Summary describes a metric over a window of intervals. Total combines
+// the intervals as given by the metric's Aggregate.
+type Summary struct {
+	Metric    string  `json:"metric"`
+	Path      string  `json:"path"`
+	Aggregate string  `json:"aggregate"`
+	Total     float64 `json:"total"`
+	Mean      float64 `json:"mean"`
+	Min       float64 `json:"min"`
+	Max       float64 `json:"max"`
+	Peak      string  `json:"peak_interval,omitempty"`
+	Intervals int     `json:"intervals"`
+}
+
+// Summarize summarises each metric over intervals.
+func Summarize(intervals []Interval, metrics []Metric) []Summary {
+	summaries := make([]Summary, len(metrics))
+	for i, m := range metrics {
+		s := Summary{Metric: m.Name, Path: m.Path, Aggregate: m.Aggregate(), Intervals: len(intervals)}
+		values := Values(intervals, m)
+		sum, peak := 0.0, -1
+		for j, v := range values {
+			sum += v
+			if j == 0 || v < s.Min {
+				s.Min = v
+			}
+			if peak < 0 || v > values[peak] {
+				peak = j
+			}
+		}
+		if peak >= 0 {
+			s.Max = values[peak]
+			s.Mean = sum / float64(len(values))
+			if s.Max > 0 {
+				s.Peak = intervals[peak].ID
+			}
+		}
+		switch s.Aggregate {
+		case "max":
+			s.Total = s.Max
+		case "min":
+			s.Total = s.Min
+		case "mean":
+			s.Total = s.Mean
+		default:
+			s.Total = sum
+		}
+		summaries[i] = s
+	}
+	return summaries
+}
+
+// Comparison compares a metric over a window with a baseline window.
+type Comparison struct {
+	Metric        string   `json:"metric"`
+	Aggregate     string   `json:"aggregate"`
+	Current       float64  `json:"current"`
+	Baseline      float64  `json:"baseline"`
+	Delta         float64  `json:"delta"`
+	ChangePercent *float64 `json:"change_percent,omitempty"` // Unset when the baseline is zero.
+}
+
+// Compare compares each metric over current with baseline.
+func Compare(current, baseline []Interval, metrics []Metric) []Comparison {
+	cur, base := Summarize(current, metrics), Summarize(baseline, metrics)
+	comparisons := make([]Comparison, len(metrics))
+	for i := range metrics {
+		c := Comparison{
+			Metric:    cur[i].Metric,
+			Aggregate: cur[i].Aggregate,
+			Current:   cur[i].Total,
+			Baseline:  base[i].Total,
+			Delta:     cur[i].Total - base[i].Total,
+		}
+		if c.Baseline != 0 {
+			change := c.Delta / c.Baseline * 100
+			c.ChangePercent = &change
+		}
+		comparisons[i] = c
+	}
+	return comparisons
+}
+
+// Distribution describes the per-interval values of a metric.
+type Distribution struct {
+	Metric string  `json:"metric"`
+	Peak   float64 `json:"peak"`
+	PeakAt string  `json:"peak_interval,omitempty"`
+	Mean   float64 `json:"mean"`
+	P50    float64 `json:"p50"`
+	P90    float64 `json:"p90"`
+	P95    float64 `json:"p95"`
+	P99    float64 `json:"p99"`
+}
+
+// Distribute computes the peak and percentiles of each metric per interval.
+func Distribute(intervals []Interval, metrics []Metric) []Distribution {
+	summaries := Summarize(intervals, metrics)
+	distributions := make([]Distribution, len(metrics))
+	for i, m := range metrics {
+		sorted := Values(intervals, m)
+		sort.Float64s(sorted)
+		distributions[i] = Distribution{
+			Metric: m.Name,
+			Peak:   summaries[i].Max,
+			PeakAt: summaries[i].Peak,
+			Mean:   summaries[i].Mean,
+			P50:    Percentile(sorted, 50),
+			P90:    Percentile(sorted, 90),
+			P95:    Percentile(sorted, 95),
+			P99:    Percentile(sorted, 99),
+		}
+	}
+	return distributions
+}
+
+// Anomaly is an interval in which a metric strayed from its baseline.
+type Anomaly struct {
+	Metric         string   `json:"metric"`
+	Interval       string   `json:"interval"`
+	Value          float64  `json:"value"`
+	BaselineMean   float64  `json:"baseline_mean"`
+	BaselineStddev float64  `json:"baseline_stddev"`
+	ZScore         *float64 `json:"z_score,omitempty"` // Unset when the baseline never varied.
+	Kind           string   `json:"kind"`              // spike or drop
+}
+
+// MinBaseline is the fewest preceding intervals an anomaly is judged on.
+const MinBaseline = 3
+
+// Anomalies flags, for each interval starting at or after from, the
+// metrics whose value is more than threshold standard deviations from the
+// mean of the baseline preceding intervals. Intervals preceded by fewer
+// than MinBaseline intervals are not judged. A metric that never varied
+// over its baseline is flagged on any change.
+func Anomalies(intervals []Interval, metrics []Metric, from time.Time, baseline int, threshold float64) []Anomaly {
+	anomalies := []Anomaly{}
+	for _, m := range metrics {
+		values := Values(intervals, m)
+		for i, interval := range intervals {
+			window := values[max(0, i-baseline):i]
+			if interval.Start.Before(from) || len(window) < MinBaseline {
+				continue
+			}
+			mean, stddev := meanStddev(window)
+			v := values[i]
+			a := Anomaly{Metric: m.Name, Interval: interval.ID, Value: v, BaselineMean: mean, BaselineStddev: stddev, Kind: "spike"}
+			if v < mean {
+				a.Kind = "drop"
+			}
+			if stddev == 0 {
+				if v == mean {
+					continue
+				}
+			} else {
+				z := (v - mean) / stddev
+				if math.Abs(z) <= threshold {
+					continue
+				}
+				a.ZScore = &z
+			}
+			anomalies = append(anomalies, a)
+		}
+	}
+	return anomalies
+}
+
+// Values returns the value of m in each interval.
+func Values(intervals []Interval, m Metric) []float64 {
+	values := make([]float64, len(intervals))
+	for i, interval := range intervals {
+		values[i] = interval.Value(m)
+	}
+	return values
+}
+
+// Percentile returns the p-th percentile of sorted values by the nearest
+// rank method.
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
+
+func meanStddev(values []float64) (mean, stddev float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		stddev += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(stddev / float64(len(values)))
+}
@@ -0,0 +1,135 @@
+package stats
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var (
+	testStart = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	count     = Metric{Name: "messages", Path: "all.messages.count"}
+	peak      = Metric{Name: "connections", Path: "connections.all.peak"}
+)
+
+// series returns an hourly interval per value, with the value as both the
+// count and the peak counter.
+func series(values ...float64) []Interval {
+	intervals := make([]Interval, len(values))
+	for i, v := range values {
+		start := Add(testStart, UnitHour, i)
+		intervals[i] = Interval{
+			ID:       FormatID(start, UnitHour),
+			Start:    start,
+			Counters: map[string]float64{count.Path: v, peak.Path: v},
+		}
+	}
+	return intervals
+}
+
+func TestSummarize(t *testing.T) {
+	got := Summarize(series(4, 0, 10, 2), []Metric{count, peak})
+	want := []Summary{
+		{Metric: "messages", Path: count.Path, Aggregate: "sum", Total: 16, Mean: 4, Min: 0, Max: 10, Peak: "2024-05-01:02", Intervals: 4},
+		{Metric: "connections", Path: peak.Path, Aggregate: "max", Total: 10, Mean: 4, Min: 0, Max: 10, Peak: "2024-05-01:02", Intervals: 4},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Summarize = %+v, want %+v", got, want)
+	}
+}
+
+func TestSummarizeQuiet(t *testing.T) {
+	// A window without activity has no peak interval
+	got := Summarize(series(0, 0), []Metric{count})[0]
+	if got.Total != 0 || got.Max != 0 || got.Peak != "" {
+		t.Errorf("Summarize of a quiet window = %+v", got)
+	}
+	got = Summarize(nil, []Metric{count})[0]
+	if got.Intervals != 0 || got.Mean != 0 || got.Peak != "" {
+		t.Errorf("Summarize of no intervals = %+v", got)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	got := Compare(series(5, 5), series(2, 3), []Metric{count})[0]
+	if got.Current != 10 || got.Baseline != 5 || got.Delta != 5 || got.ChangePercent == nil || *got.ChangePercent != 100 {
+		t.Errorf("Compare = %+v", got)
+	}
+	if got := Compare(series(5), series(0), []Metric{count})[0]; got.ChangePercent != nil {
+		t.Errorf("Compare against a zero baseline gave a change of %v%%", *got.ChangePercent)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		p    float64
+		want float64
+	}{
+		{0, 1},
+		{10, 1},
+		{11, 2},
+		{50, 5},
+		{90, 9},
+		{95, 10},
+		{99, 10},
+		{100, 10},
+	}
+	for _, tt := range tests {
+		if got := Percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("Percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if got := Percentile([]float64{7}, 99); got != 7 {
+		t.Errorf("Percentile of one value = %v, want 7", got)
+	}
+	if got := Percentile(nil, 50); got != 0 {
+		t.Errorf("Percentile of no values = %v, want 0", got)
+	}
+}
+
+func TestAnomalies(t *testing.T) {
+	intervals := series(10, 12, 11, 9, 10, 50, 10, 11, 9, 10, 0)
+	got := Anomalies(intervals, []Metric{count}, testStart, 4, 3)
+	if len(got) != 2 {
+		t.Fatalf("Anomalies = %+v, want the spike and the drop", got)
+	}
+	spike, drop := got[0], got[1]
+	if spike.Interval != "2024-05-01:05" || spike.Kind != "spike" || spike.Value != 50 || spike.BaselineMean != 10.5 {
+		t.Errorf("spike = %+v", spike)
+	}
+	if z := *spike.ZScore; math.Abs(z-(50-10.5)/math.Sqrt(1.25)) > 1e-9 {
+		t.Errorf("spike z-score = %v", z)
+	}
+	// The spike is only in the baseline of the intervals right after it,
+	// which it keeps from being flagged
+	if drop.Interval != "2024-05-01:10" || drop.Kind != "drop" || drop.BaselineMean != 10 {
+		t.Errorf("drop = %+v", drop)
+	}
+}
+
+func TestAnomaliesFlatBaseline(t *testing.T) {
+	got := Anomalies(series(5, 5, 5, 5, 6), []Metric{count}, testStart, 4, 3)
+	if len(got) != 1 || got[0].Interval != "2024-05-01:04" || got[0].ZScore != nil {
+		t.Errorf("Anomalies after a flat baseline = %+v, want one without a z-score", got)
+	}
+}
+
+func TestAnomaliesBaselineLength(t *testing.T) {
+	// The first intervals have too short a baseline to be judged, wherever
+	// the window starts
+	got := Anomalies(series(1, 100, 1, 100, 1), []Metric{count}, testStart, 2, 0.5)
+	if len(got) != 0 {
+		t.Errorf("Anomalies with a baseline of 2 = %+v, want none", got)
+	}
+	got = Anomalies(series(1, 1, 1, 100), []Metric{count}, testStart, 24, 3)
+	if len(got) != 1 || got[0].Interval != "2024-05-01:03" {
+		t.Errorf("Anomalies = %+v, want the interval after three baseline ones", got)
+	}
+	// Intervals before from are only a baseline
+	got = Anomalies(series(1, 1, 1, 100, 1, 1, 1, 1, 100), []Metric{count}, Add(testStart, UnitHour, 5), 3, 3)
+	if len(got) != 1 || got[0].Interval != "2024-05-01:08" {
+		t.Errorf("Anomalies from the sixth interval = %+v, want the last spike only", got)
+	}
+}
@@ -0,0 +1,66 @@
+package stats
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/platform-api/mcp-server/ably"
+)
+
+// DefaultWindow is the number of intervals a window spans when its start
+// is not given.
+const DefaultWindow = 24
+
+// UnitArg reads the unit argument, which defaults to hours.
+func UnitArg(args map[string]any) (string, error) {
+	val, ok := args["unit"]
+	if !ok {
+		return UnitHour, nil
+	}
+	unit, ok := val.(string)
+	if !ok || !ValidUnit(unit) {
+		return "", fmt.Errorf("invalid parameter: unit must be minute, hour, day or month")
+	}
+	return unit, nil
+}
+
+// WindowArgs reads a time window from the named arguments, parsed with
+// ably.ParseTime. The end defaults to now and the start to DefaultWindow
+// intervals of unit before the end.
+func WindowArgs(args map[string]any, startName, endName, unit string, now time.Time) (start, end time.Time, err error) {
+	end = now
+	if val, ok := args[endName]; ok {
+		if end, err = ably.ParseTime(val, now); err != nil {
+			return start, end, fmt.Errorf("invalid parameter %s: %v", endName, err)
+		}
+	}
+	start = Add(end, unit, -DefaultWindow)
+	if val, ok := args[startName]; ok {
+		if start, err = ably.ParseTime(val, now); err != nil {
+			return start, end, fmt.Errorf("invalid parameter %s: %v", startName, err)
+		}
+	}
+	if start.After(end) {
+		return start, end, fmt.Errorf("invalid time range: %s is after %s", startName, endName)
+	}
+	return start, end, nil
+}
+
+// MetricsArg reads the metrics argument, see ParseMetrics.
+func MetricsArg(args map[string]any) ([]Metric, error) {
+	var names []string
+	if val, ok := args["metrics"]; ok {
+		list, ok := val.([]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid parameter: metrics must be an array of strings")
+		}
+		for _, item := range list {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid parameter: metrics must be an array of strings")
+			}
+			names = append(names, name)
+		}
+	}
+	return ParseMetrics(names)
+}
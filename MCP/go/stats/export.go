@@ -0,0 +1,286 @@
+package stats
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/platform-api/mcp-server/ably"
+)
+
+// Export formats.
+const (
+	FormatCSV        = "csv"
+	FormatJSONL      = "jsonl"
+	FormatPrometheus = "prometheus"
+)
+
+// ContentTypes holds the media type of each export format.
+var ContentTypes = map[string]string{
+	FormatCSV:        "text/csv; charset=utf-8",
+	FormatJSONL:      "application/jsonl; charset=utf-8",
+	FormatPrometheus: "text/plain; version=0.0.4; charset=utf-8",
+}
+
+// Series is the intervals of one unit over a window ending at End, oldest
+// first.
+type Series struct {
+	Unit      string
+	End       time.Time
+	Intervals []Interval
+}
+
+// Window is the time range exported for one unit.
+type Window struct {
+	Unit       string
+	Start, End time.Time
+}
+
+// Export is a parsed export of stats: the counters of Metrics over each of
+// Windows, rendered as Format.
+type Export struct {
+	Format  string
+	Metrics []Metric
+	Windows []Window
+}
+
+// ExportArgs reads an export from the format, unit, start, end and metrics
+// arguments. The format defaults to CSV and the unit to hours; several
+// units may be given separated by commas, each over its own window as read
+// by WindowArgs. Without metrics every counter is exported.
+func ExportArgs(args map[string]any, now time.Time) (*Export, error) {
+	e := &Export{Format: FormatCSV}
+	if val, ok := args["format"]; ok {
+		format, ok := val.(string)
+		if _, known := ContentTypes[format]; !ok || !known {
+			return nil, fmt.Errorf("invalid parameter: format must be csv, jsonl or prometheus")
+		}
+		e.Format = format
+	}
+
+	units := []string{UnitHour}
+	if val, ok := args["unit"]; ok {
+		s, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid parameter: unit must be a string")
+		}
+		units = strings.Split(s, ",")
+	}
+	seen := make(map[string]bool)
+	for _, unit := range units {
+		unit = strings.TrimSpace(unit)
+		if !ValidUnit(unit) {
+			return nil, fmt.Errorf("invalid parameter: unit must be minute, hour, day or month, or several of them separated by commas")
+		}
+		if seen[unit] {
+			continue
+		}
+		seen[unit] = true
+		start, end, err := WindowArgs(args, "start", "end", unit, now)
+		if err != nil {
+			return nil, err
+		}
+		if n := Count(Truncate(start, unit), Truncate(end, unit), unit); n > MaxIntervals {
+			return nil, fmt.Errorf("the time range spans %d %s intervals, more than the %d that can be exported; use a coarser unit", n, unit, MaxIntervals)
+		}
+		e.Windows = append(e.Windows, Window{Unit: unit, Start: start, End: end})
+	}
+
+	if _, ok := args["metrics"]; ok {
+		metrics, err := MetricsArg(args)
+		if err != nil {
+			return nil, err
+		}
+		e.Metrics = metrics
+	} else {
+		e.Metrics = AllMetrics()
+	}
+	return e, nil
+}
+
+// Run fetches the series of the export and renders them. Nothing is
+// written unless every series was fetched.
+func (e *Export) Run(ctx context.Context, c *ably.Client, w io.Writer) error {
+	series := make([]Series, 0, len(e.Windows))
+	for _, window := range e.Windows {
+		intervals, err := Fetch(ctx, c, window.Unit, window.Start, window.End)
+		if err != nil {
+			return err
+		}
+		series = append(series, Series{Unit: window.Unit, End: window.End, Intervals: intervals})
+	}
+	var buf bytes.Buffer
+	if err := Render(&buf, e.Format, series, e.Metrics); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// AllMetrics returns a metric for every counter, ordered by path.
+func AllMetrics() []Metric {
+	metrics := make([]Metric, 0, len(paths))
+	for path := range paths {
+		metrics = append(metrics, Metric{Name: path, Path: path})
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Path < metrics[j].Path })
+	return metrics
+}
+
+// Render writes the metrics of series to w in the given format, grouped by
+// unit in the order of series.
+func Render(w io.Writer, format string, series []Series, metrics []Metric) error {
+	switch format {
+	case FormatCSV:
+		return renderCSV(w, series, metrics)
+	case FormatJSONL:
+		return renderJSONL(w, series, metrics)
+	case FormatPrometheus:
+		return renderPrometheus(w, series, metrics)
+	}
+	return fmt.Errorf("unknown export format %q", format)
+}
+
+// renderCSV writes a header row, then a row per interval.
+func renderCSV(w io.Writer, series []Series, metrics []Metric) error {
+	cw := csv.NewWriter(w)
+	header := []string{"unit", "intervalId", "start"}
+	for _, m := range metrics {
+		header = append(header, m.Name)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, s := range series {
+		for _, interval := range s.Intervals {
+			row := []string{s.Unit, interval.ID, interval.Start.Format(ably.TimeLayout)}
+			for _, m := range metrics {
+				row = append(row, strconv.FormatFloat(interval.Value(m), 'f', -1, 64))
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// exportRow is a line of a JSON Lines export.
+type exportRow struct {
+	Unit       string             `json:"unit"`
+	Intervalid string             `json:"intervalId"`
+	Start      string             `json:"start"`
+	Metrics    map[string]float64 `json:"metrics"`
+}
+
+// renderJSONL writes a JSON object per interval.
+func renderJSONL(w io.Writer, series []Series, metrics []Metric) error {
+	enc := json.NewEncoder(w)
+	for _, s := range series {
+		for _, interval := range s.Intervals {
+			row := exportRow{
+				Unit:       s.Unit,
+				Intervalid: interval.ID,
+				Start:      interval.Start.Format(ably.TimeLayout),
+				Metrics:    make(map[string]float64, len(metrics)),
+			}
+			for _, m := range metrics {
+				row.Metrics[m.Name] = interval.Value(m)
+			}
+			if err := enc.Encode(row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// prometheusIntervalStart names the gauge of the start of the interval
+// each unit's samples describe.
+const prometheusIntervalStart = "ably_stats_interval_start_seconds"
+
+// renderPrometheus writes a gauge family per metric, named after its
+// counter path, with a sample per unit labelled with the unit. Prometheus
+// rejects samples stamped far in the past, so the exposition is that of a
+// scrape target: only the latest complete interval of each unit is
+// written, without timestamps, and its start is given by a gauge of its
+// own.
+func renderPrometheus(w io.Writer, series []Series, metrics []Metric) error {
+	type sample struct {
+		unit     string
+		interval *Interval
+	}
+	var latest []sample
+	for _, s := range series {
+		if interval := s.latestComplete(); interval != nil {
+			latest = append(latest, sample{s.Unit, interval})
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "# HELP %s Start of the latest complete stats interval of the unit, in seconds since the epoch.\n# TYPE %s gauge\n", prometheusIntervalStart, prometheusIntervalStart); err != nil {
+		return err
+	}
+	for _, l := range latest {
+		if _, err := fmt.Fprintf(w, "%s{unit=%q} %d\n", prometheusIntervalStart, l.unit, l.interval.Start.Unix()); err != nil {
+			return err
+		}
+	}
+	written := make(map[string]bool)
+	for _, m := range metrics {
+		name := PrometheusName(m.Path)
+		if written[name] {
+			continue
+		}
+		written[name] = true
+		if _, err := fmt.Fprintf(w, "# HELP %s Ably stats counter %s over the latest complete interval.\n# TYPE %s gauge\n", name, m.Path, name); err != nil {
+			return err
+		}
+		for _, l := range latest {
+			if _, err := fmt.Fprintf(w, "%s{unit=%q} %s\n", name, l.unit, strconv.FormatFloat(l.interval.Value(m), 'g', -1, 64)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// latestComplete returns the last interval of s that was over by the end
+// of its window, or nil if none was.
+func (s Series) latestComplete() *Interval {
+	for i := len(s.Intervals) - 1; i >= 0; i-- {
+		if !Add(s.Intervals[i].Start, s.Unit, 1).After(s.End) {
+			return &s.Intervals[i]
+		}
+	}
+	return nil
+}
+
+// PrometheusName returns the Prometheus metric name of a counter path, e.g.
+// ably_stats_api_requests_succeeded for apiRequests.succeeded.
+func PrometheusName(path string) string {
+	var b strings.Builder
+	b.WriteString("ably_stats_")
+	for i, r := range path {
+		switch {
+		case r == '.':
+			b.WriteByte('_')
+		case unicode.IsUpper(r):
+			if i > 0 && path[i-1] != '.' {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package stats
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestRenderPrometheus(t *testing.T) {
+	// The window ends within the interval starting at 02:00, which is not
+	// complete yet
+	hours := Series{Unit: UnitHour, End: Add(testStart, UnitHour, 2).Add(30 * time.Minute), Intervals: series(4, 7, 1)}
+	// No day interval is complete
+	days := Series{Unit: UnitDay, End: testStart.Add(time.Hour), Intervals: series(12)}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatPrometheus, []Series{hours, days}, []Metric{count, count}); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP ably_stats_interval_start_seconds Start of the latest complete stats interval of the unit, in seconds since the epoch.
+# TYPE ably_stats_interval_start_seconds gauge
+ably_stats_interval_start_seconds{unit="hour"} 1714525200
+# HELP ably_stats_all_messages_count Ably stats counter all.messages.count over the latest complete interval.
+# TYPE ably_stats_all_messages_count gauge
+ably_stats_all_messages_count{unit="hour"} 7
+`
+	if buf.String() != want {
+		t.Errorf("exposition:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestLatestCompleteAtWindowEnd(t *testing.T) {
+	// An interval ending exactly at the end of the window is complete
+	s := Series{Unit: UnitHour, End: Add(testStart, UnitHour, 2), Intervals: series(4, 7, 1)}
+	if got := s.latestComplete(); got == nil || got.ID != "2024-05-01:01" {
+		t.Errorf("latestComplete = %+v, want 2024-05-01:01", got)
+	}
+}
+
+func TestPrometheusName(t *testing.T) {
+	tests := map[string]string{
+		"apiRequests.succeeded":           "ably_stats_api_requests_succeeded",
+		"inbound.realtime.messages.count": "ably_stats_inbound_realtime_messages_count",
+		"connections.all.peak":            "ably_stats_connections_all_peak",
+	}
+	for path, want := range tests {
+		if got := PrometheusName(path); got != want {
+			t.Errorf("PrometheusName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
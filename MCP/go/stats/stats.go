@@ -0,0 +1,283 @@
+// Package stats fetches Ably application statistics and analyses them. Each
+// stats interval is flattened into metrics named by the dotted JSON path of
+// a counter, e.g. "inbound.realtime.messages.count", or by one of the
+// friendlier aliases in Aliases.
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/platform-api/mcp-server/ably"
+	"github.com/platform-api/mcp-server/models"
+)
+
+// Units of aggregation of Ably stats.
+const (
+	UnitMinute = "minute"
+	UnitHour   = "hour"
+	UnitDay    = "day"
+	UnitMonth  = "month"
+)
+
+// MaxIntervals bounds the number of intervals a single analysis may cover.
+const MaxIntervals = 10000
+
+// Aliases maps friendly metric names to the stats counters they stand for.
+var Aliases = map[string]string{
+	"messages":            "all.all.count",
+	"message_bytes":       "all.all.data",
+	"inbound_messages":    "inbound.all.all.count",
+	"outbound_messages":   "outbound.all.all.count",
+	"persisted_messages":  "persisted.all.count",
+	"connections":         "connections.all.peak",
+	"connections_opened":  "connections.all.opened",
+	"channels":            "channels.peak",
+	"api_requests":        "apiRequests.succeeded",
+	"api_requests_failed": "apiRequests.failed",
+	"token_requests":      "tokenRequests.succeeded",
+	"push_messages":       "push.messages",
+	"push_delivered":      "push.notifications.successful",
+}
+
+// DefaultMetrics are analysed when no metrics are requested.
+var DefaultMetrics = []string{"messages", "inbound_messages", "outbound_messages", "connections", "channels", "api_requests", "api_requests_failed", "push_messages"}
+
+// paths holds the dotted path of every counter in models.Stats.
+var paths = counterPaths(reflect.TypeOf(models.Stats{}), "")
+
+// Metric is a counter to analyse: Name as requested and the Path of the
+// counter it resolves to.
+type Metric struct {
+	Name string
+	Path string
+}
+
+// Aggregate returns how values of m combine over several intervals: peaks
+// by their maximum, minimums by their minimum, means by their mean and
+// every other counter by its sum.
+func (m Metric) Aggregate() string {
+	switch m.Path[strings.LastIndex(m.Path, ".")+1:] {
+	case "peak":
+		return "max"
+	case "min":
+		return "min"
+	case "mean":
+		return "mean"
+	}
+	return "sum"
+}
+
+// ParseMetrics resolves metric names, which are aliases or counter paths.
+// No names selects DefaultMetrics.
+func ParseMetrics(names []string) ([]Metric, error) {
+	if len(names) == 0 {
+		names = DefaultMetrics
+	}
+	metrics := make([]Metric, 0, len(names))
+	for _, name := range names {
+		path, ok := Aliases[name]
+		if !ok {
+			path = name
+		}
+		if !paths[path] {
+			return nil, fmt.Errorf("unknown metric %q; use one of the aliases %s or a counter path such as inbound.realtime.messages.count", name, strings.Join(aliasNames(), ", "))
+		}
+		metrics = append(metrics, Metric{Name: name, Path: path})
+	}
+	return metrics, nil
+}
+
+// Interval is the statistics of one interval, flattened into counters.
+type Interval struct {
+	ID       string
+	Start    time.Time
+	Counters map[string]float64
+}
+
+// Value returns the value of m in the interval.
+func (i Interval) Value(m Metric) float64 {
+	return i.Counters[m.Path]
+}
+
+// Fetch returns the intervals of the given unit from start to end, oldest
+// first. Intervals in which nothing happened, which Ably omits, are
+// included with all counters zero.
+func Fetch(ctx context.Context, c *ably.Client, unit string, start, end time.Time) ([]Interval, error) {
+	first, last := Truncate(start, unit), Truncate(end, unit)
+	if n := Count(first, last, unit); n > MaxIntervals {
+		return nil, fmt.Errorf("the time range spans %d %s intervals, more than the %d that can be analysed; use a coarser unit", n, unit, MaxIntervals)
+	}
+
+	query := url.Values{}
+	query.Set("unit", unit)
+	query.Set("direction", "forwards")
+	query.Set("limit", "1000")
+	query.Set("start", strconv.FormatInt(first.UnixMilli(), 10))
+	query.Set("end", strconv.FormatInt(end.UnixMilli(), 10))
+	rows, _, err := ably.Paginate[models.Stats](ctx, c, &ably.Request{
+		Method: http.MethodGet,
+		Path:   ably.Path("stats"),
+		Query:  query,
+	}, "", ably.MaxPages)
+	if err != nil {
+		return nil, err
+	}
+
+	byStart := make(map[time.Time]Interval, len(rows))
+	for _, row := range rows {
+		interval, err := newInterval(row)
+		if err != nil {
+			return nil, err
+		}
+		byStart[interval.Start] = interval
+	}
+	var intervals []Interval
+	for t := first; !t.After(last); t = Add(t, unit, 1) {
+		interval, ok := byStart[t]
+		if !ok {
+			interval = Interval{ID: FormatID(t, unit), Start: t, Counters: map[string]float64{}}
+		}
+		intervals = append(intervals, interval)
+	}
+	return intervals, nil
+}
+
+func newInterval(row models.Stats) (Interval, error) {
+	start, err := ParseID(row.Intervalid)
+	if err != nil {
+		return Interval{}, err
+	}
+	raw, err := json.Marshal(row)
+	if err != nil {
+		return Interval{}, err
+	}
+	var generic map[string]any
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return Interval{}, err
+	}
+	counters := make(map[string]float64)
+	flatten(generic, "", counters)
+	return Interval{ID: row.Intervalid, Start: start, Counters: counters}, nil
+}
+
+func flatten(v map[string]any, prefix string, out map[string]float64) {
+	for k, v := range v {
+		switch v := v.(type) {
+		case float64:
+			out[prefix+k] = v
+		case map[string]any:
+			flatten(v, prefix+k+".", out)
+		}
+	}
+}
+
+// Layouts of interval IDs, by unit.
+var idLayouts = map[string]string{
+	UnitMinute: "2006-01-02:15:04",
+	UnitHour:   "2006-01-02:15",
+	UnitDay:    "2006-01-02",
+	UnitMonth:  "2006-01",
+}
+
+// ParseID returns the start of the interval with the given ID.
+func ParseID(id string) (time.Time, error) {
+	for _, layout := range idLayouts {
+		if len(id) == len(layout) {
+			if t, err := time.Parse(layout, id); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid stats interval ID %q", id)
+}
+
+// FormatID returns the ID of the interval of the given unit starting at t.
+func FormatID(t time.Time, unit string) string {
+	return t.UTC().Format(idLayouts[unit])
+}
+
+// ValidUnit reports whether unit is a unit of aggregation of Ably stats.
+func ValidUnit(unit string) bool {
+	_, ok := idLayouts[unit]
+	return ok
+}
+
+// Truncate returns the start of the interval of the given unit holding t.
+func Truncate(t time.Time, unit string) time.Time {
+	t = t.UTC()
+	switch unit {
+	case UnitMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case UnitDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case UnitHour:
+		return t.Truncate(time.Hour)
+	}
+	return t.Truncate(time.Minute)
+}
+
+// Add moves t by n intervals of the given unit.
+func Add(t time.Time, unit string, n int) time.Time {
+	switch unit {
+	case UnitMonth:
+		return t.AddDate(0, n, 0)
+	case UnitDay:
+		return t.AddDate(0, 0, n)
+	case UnitHour:
+		return t.Add(time.Duration(n) * time.Hour)
+	}
+	return t.Add(time.Duration(n) * time.Minute)
+}
+
+// Count returns the number of intervals of the given unit from the one
+// starting at first to the one starting at last, both included.
+func Count(first, last time.Time, unit string) int {
+	if last.Before(first) {
+		return 0
+	}
+	switch unit {
+	case UnitMonth:
+		return (last.Year()-first.Year())*12 + int(last.Month()-first.Month()) + 1
+	case UnitDay:
+		return int(last.Sub(first).Hours()/24) + 1
+	case UnitHour:
+		return int(last.Sub(first).Hours()) + 1
+	}
+	return int(last.Sub(first).Minutes()) + 1
+}
+
+// counterPaths returns the dotted JSON paths of the numeric fields of t.
+func counterPaths(t reflect.Type, prefix string) map[string]bool {
+	out := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch field.Type.Kind() {
+		case reflect.Float64:
+			out[prefix+name] = true
+		case reflect.Struct:
+			for path := range counterPaths(field.Type, prefix+name+".") {
+				out[path] = true
+			}
+		}
+	}
+	return out
+}
+
+func aliasNames() []string {
+	names := make([]string, 0, len(Aliases))
+	for name := range Aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"context"
+	"sort"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
+	"github.com/platform-api/mcp-server/config"
+	"github.com/platform-api/mcp-server/models"
+)
+
+// capabilityExplanation is the result of explain_capability.
+type capabilityExplanation struct {
+	Capability ably.Capability       `json:"capability"`
+	Rules      []ably.CapabilityRule `json:"rules"`
+	Channel    string                `json:"channel,omitempty"`
+	Allowed    []string              `json:"allowed,omitempty"`
+}
+
+func ExplaincapabilityHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		capabilityVal, ok := args["capability"]
+		if !ok {
+			return mcp.NewToolResultError("Missing required parameter: capability"), nil
+		}
+		capability, err := ably.ParseCapability(capabilityVal)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		result := capabilityExplanation{
+			Capability: capability,
+			Rules:      capability.Explain(),
+		}
+		if channel, ok := args["channel"].(string); ok && channel != "" {
+			result.Channel = channel
+			result.Allowed = []string{}
+			for op := range ably.Operations {
+				if op != "*" && capability.Allows(channel, op) {
+					result.Allowed = append(result.Allowed, op)
+				}
+			}
+			sort.Strings(result.Allowed)
+		}
+		return ably.ToolResult(result)
+	}
+}
+
+func CreateExplaincapabilityTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("explain_capability",
+		mcp.WithDescription("Validate an Ably capability and explain which channels and operations it grants"),
+		mcp.WithObject("capability", mcp.Required(), mcp.Description("The [capability](https://www.ably.io/documentation/core-features/authentication#capabilities-explained) to explain: a map of channel names/namespaces to operations. The JSON text from TokenDetails.capability is also accepted.")),
+		mcp.WithString("channel", mcp.Description("Optional channel name; when set the result lists the operations allowed on that channel.")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    ExplaincapabilityHandler(cfg),
+	}
+}
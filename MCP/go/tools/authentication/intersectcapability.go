@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
+	"github.com/platform-api/mcp-server/config"
+	"github.com/platform-api/mcp-server/models"
+)
+
+// capabilityIntersection is the result of intersect_capability.
+type capabilityIntersection struct {
+	Requested ably.Capability `json:"requested"`
+	Key       ably.Capability `json:"key"`
+	Effective ably.Capability `json:"effective"`
+	IsSubset  bool            `json:"isSubset"`
+	Exceeding ably.Capability `json:"exceeding,omitempty"`
+}
+
+func IntersectcapabilityHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		capabilityVal, ok := args["capability"]
+		if !ok {
+			return mcp.NewToolResultError("Missing required parameter: capability"), nil
+		}
+		requested, err := ably.ParseCapability(capabilityVal)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		var key ably.Capability
+		if keyVal, ok := args["keyCapability"]; ok {
+			if key, err = ably.ParseKeyCapability(keyVal); err != nil {
+				return mcp.NewToolResultError("Invalid keyCapability: " + err.Error()), nil
+			}
+		} else if key, err = client.KeyCapability(ctx); err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to determine the key capability; pass keyCapability explicitly", err), nil
+		}
+
+		exceeding := requested.Exceeding(key)
+		return ably.ToolResult(capabilityIntersection{
+			Requested: requested,
+			Key:       key,
+			Effective: requested.Intersect(key),
+			IsSubset:  len(exceeding) == 0,
+			Exceeding: exceeding,
+		})
+	}
+}
+
+func CreateIntersectcapabilityTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("intersect_capability",
+		mcp.WithDescription("Compute the capability a token would actually receive when requesting a capability from a key, and whether the request is a subset of the key capability"),
+		mcp.WithObject("capability", mcp.Required(), mcp.Description("The requested [capability](https://www.ably.io/documentation/core-features/authentication#capabilities-explained): a map of channel names/namespaces to operations.")),
+		mcp.WithObject("keyCapability", mcp.Description("The capability of the key. Defaults to the capability of the configured API key. Operations this server does not know are kept as they are.")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    IntersectcapabilityHandler(cfg),
+	}
+}
@@ -4,16 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"bytes"
+	"time"
 
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
 	"github.com/platform-api/mcp-server/config"
 	"github.com/platform-api/mcp-server/models"
-	"github.com/mark3labs/mcp-go/mcp"
 )
 
 func RequestaccesstokenHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		args, ok := request.Params.Arguments.(map[string]any)
 		if !ok {
@@ -27,9 +27,31 @@ func RequestaccesstokenHandler(cfg *config.APIConfig) func(ctx context.Context,
 		if !ok {
 			return mcp.NewToolResultError("Invalid path parameter: keyName"), nil
 		}
+		// A mac argument means the caller already holds a SignedTokenRequest
+		if _, signed := args["mac"]; signed {
+			var requestBody models.SignedTokenRequest
+			if argsJSON, err := json.Marshal(args); err == nil {
+				if err := json.Unmarshal(argsJSON, &requestBody); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to convert arguments to request type: %v", err)), nil
+				}
+			} else {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal arguments: %v", err)), nil
+			}
+			if requestBody.Nonce == "" || requestBody.Timestamp == 0 {
+				return mcp.NewToolResultError("A signed token request requires the nonce and timestamp it was signed with"), nil
+			}
+			requestBody.Keyname = keyName
+
+			details, err := client.RequestSignedToken(ctx, &requestBody)
+			if err != nil {
+				return ably.ErrorResult(err), nil
+			}
+			return ably.ToolResult(newTokenDetailsResult(details, client.Now()))
+		}
+
 		// Create properly typed request body using the generated schema
 		var requestBody models.TokenRequest
-		
+
 		// Optimized: Single marshal/unmarshal with JSON tags handling field mapping
 		if argsJSON, err := json.Marshal(args); err == nil {
 			if err := json.Unmarshal(argsJSON, &requestBody); err != nil {
@@ -38,57 +60,50 @@ func RequestaccesstokenHandler(cfg *config.APIConfig) func(ctx context.Context,
 		} else {
 			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal arguments: %v", err)), nil
 		}
-		
-		bodyBytes, err := json.Marshal(requestBody)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to encode request body", err), nil
-		}
-		url := fmt.Sprintf("%s/keys/%s/requestToken", cfg.BaseURL, keyName)
-		req, err := http.NewRequest("POST", url, bytes.NewBuffer(bodyBytes))
-		req.Header.Set("Content-Type", "application/json")
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to create request", err), nil
-		}
-		// Set authentication based on auth type
-		if cfg.BasicAuth != "" {
-			req.Header.Set("Authorization", fmt.Sprintf("Basic %s", cfg.BasicAuth))
+		requestBody.Keyname = keyName
+		if err := ably.PrepareTokenRequest(&requestBody, client.Now()); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid token request: %v", err)), nil
 		}
-		req.Header.Set("Accept", "application/json")
 
-		resp, err := http.DefaultClient.Do(req)
+		details, err := client.RequestToken(ctx, &requestBody)
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Request failed", err), nil
-		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to read response body", err), nil
-		}
-
-		if resp.StatusCode >= 400 {
-			return mcp.NewToolResultError(fmt.Sprintf("API error: %s", body)), nil
-		}
-		// Use properly typed response
-		var result models.Error
-		if err := json.Unmarshal(body, &result); err != nil {
-			// Fallback to raw text if unmarshaling fails
-			return mcp.NewToolResultText(string(body)), nil
+			return ably.ErrorResult(err), nil
 		}
+		return ably.ToolResult(newTokenDetailsResult(details, client.Now()))
+	}
+}
 
-		prettyJSON, err := json.MarshalIndent(result, "", "  ")
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
-		}
+// tokenDetailsResult adds human-readable times to TokenDetails.
+type tokenDetailsResult struct {
+	models.TokenDetails
+	IssuedAt  string `json:"issuedAt,omitempty"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+	ExpiresIn string `json:"expiresIn,omitempty"`
+}
 
-		return mcp.NewToolResultText(string(prettyJSON)), nil
+func newTokenDetailsResult(details *models.TokenDetails, now time.Time) tokenDetailsResult {
+	result := tokenDetailsResult{TokenDetails: *details}
+	if details.Issued > 0 {
+		result.IssuedAt = time.UnixMilli(int64(details.Issued)).UTC().Format(time.RFC3339)
+	}
+	if details.Expires > 0 {
+		expires := time.UnixMilli(int64(details.Expires))
+		result.ExpiresAt = expires.UTC().Format(time.RFC3339)
+		result.ExpiresIn = expires.Sub(now).Round(time.Second).String()
 	}
+	return result
 }
 
 func CreateRequestaccesstokenTool(cfg *config.APIConfig) models.Tool {
 	tool := mcp.NewTool("post_keys_keyName_requestToken",
 		mcp.WithDescription("Request an access token"),
 		mcp.WithString("keyName", mcp.Required(), mcp.Description("The [key name](https://www.ably.io/documentation/rest-api/token-request-spec#api-key-format) comprises of the app ID and key ID of an API key.")),
+		mcp.WithObject("capability", mcp.Description("Input parameter: The [capabilities](https://www.ably.io/documentation/core-features/authentication#capabilities-explained) (i.e. a set of channel names/namespaces and, for each, a set of operations) which should be a subset of the set of capabilities associated with the key specified in keyName, as an object or as its JSON text as in a signed request. Defaults to all capabilities of the key.")),
+		mcp.WithString("clientId", mcp.Description("Input parameter: The [client ID](https://www.ably.io/documentation/core-features/authentication#identified-clients) to be assosciated with the token. Can be set to * to allow for any client ID to be used.")),
+		mcp.WithString("nonce", mcp.Description("Input parameter: An unquoted, un-escaped random string of at least 16 characters. Used to ensure the Ably TokenRequest cannot be reused. Generated when omitted.")),
+		mcp.WithNumber("timestamp", mcp.Description("Input parameter: Time of creation of the Ably TokenRequest, in milliseconds since the epoch. Defaults to now.")),
+		mcp.WithNumber("ttl", mcp.Description("Input parameter: Requested time to live for the token in milliseconds. Defaults to 60 minutes.")),
+		mcp.WithString("mac", mcp.Description("Input parameter: The signature of a [SignedTokenRequest](https://www.ably.io/documentation/rest-api/token-request-spec), e.g. from sign_token_request. When set, the other fields must match the signed request exactly and no credentials are sent.")),
 	)
 
 	return models.Tool{
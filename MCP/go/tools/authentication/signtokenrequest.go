@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
+	"github.com/platform-api/mcp-server/config"
+	"github.com/platform-api/mcp-server/models"
+)
+
+func SigntokenrequestHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		// Default to the configured API key; explicit arguments override it
+		var keyName, keySecret string
+		if cfg.APIKey != "" {
+			var err error
+			if keyName, keySecret, err = ably.SplitKey(cfg.APIKey); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+		if val, ok := args["keyName"].(string); ok && val != "" {
+			if val != keyName {
+				keySecret = ""
+			}
+			keyName = val
+		}
+		if val, ok := args["keySecret"].(string); ok && val != "" {
+			keySecret = val
+		}
+		if keyName == "" || keySecret == "" {
+			return mcp.NewToolResultError("Missing key: pass keyName and keySecret or configure API_KEY"), nil
+		}
+
+		var params models.TokenRequest
+		if argsJSON, err := json.Marshal(args); err == nil {
+			if err := json.Unmarshal(argsJSON, &params); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to convert arguments to request type: %v", err)), nil
+			}
+		} else {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal arguments: %v", err)), nil
+		}
+		params.Keyname = keyName
+
+		signed, err := ably.SignTokenRequest(keyName, keySecret, params, ably.Now(cfg.BaseURL))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return ably.ToolResult(signed)
+	}
+}
+
+func CreateSigntokenrequestTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("sign_token_request",
+		mcp.WithDescription("Create a signed TokenRequest locally, without contacting Ably. The result can be handed to a client and exchanged for a token via post_keys_keyName_requestToken."),
+		mcp.WithString("keyName", mcp.Description("The key name (appId.keyId) to sign for. Defaults to the key name of the configured API key.")),
+		mcp.WithString("keySecret", mcp.Description("The secret of keyName. Defaults to the secret of the configured API key when keyName matches it.")),
+		mcp.WithObject("capability", mcp.Description("Input parameter: The [capabilities](https://www.ably.io/documentation/core-features/authentication#capabilities-explained) for the token, as a map of channel names/namespaces to operations. Defaults to all capabilities of the key.")),
+		mcp.WithString("clientId", mcp.Description("Input parameter: The [client ID](https://www.ably.io/documentation/core-features/authentication#identified-clients) to be assosciated with the token. Can be set to * to allow for any client ID to be used.")),
+		mcp.WithNumber("ttl", mcp.Description("Requested time to live for the token in milliseconds. Defaults to 60 minutes.")),
+		mcp.WithNumber("timestamp", mcp.Description("Time of creation of the TokenRequest in milliseconds since the epoch. Defaults to now.")),
+		mcp.WithString("nonce", mcp.Description("Random string of at least 16 characters. Generated when omitted.")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    SigntokenrequestHandler(cfg),
+	}
+}
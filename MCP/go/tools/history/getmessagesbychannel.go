@@ -2,97 +2,98 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"strings"
+	"net/url"
+	"time"
 
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
 	"github.com/platform-api/mcp-server/config"
 	"github.com/platform-api/mcp-server/models"
-	"github.com/mark3labs/mcp-go/mcp"
 )
 
 func GetmessagesbychannelHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		args, ok := request.Params.Arguments.(map[string]any)
 		if !ok {
 			return mcp.NewToolResultError("Invalid arguments object"), nil
 		}
-		channel_idVal, ok := args["channel_id"]
-		if !ok {
-			return mcp.NewToolResultError("Missing required path parameter: channel_id"), nil
-		}
-		channel_id, ok := channel_idVal.(string)
-		if !ok {
-			return mcp.NewToolResultError("Invalid path parameter: channel_id"), nil
-		}
-		queryParams := make([]string, 0)
-		if val, ok := args["start"]; ok {
-			queryParams = append(queryParams, fmt.Sprintf("start=%v", val))
-		}
-		if val, ok := args["limit"]; ok {
-			queryParams = append(queryParams, fmt.Sprintf("limit=%v", val))
-		}
-		if val, ok := args["end"]; ok {
-			queryParams = append(queryParams, fmt.Sprintf("end=%v", val))
-		}
-		if val, ok := args["direction"]; ok {
-			queryParams = append(queryParams, fmt.Sprintf("direction=%v", val))
-		}
-		queryString := ""
-		if len(queryParams) > 0 {
-			queryString = "?" + strings.Join(queryParams, "&")
-		}
-		url := fmt.Sprintf("%s/channels/%s/messages%s", cfg.BaseURL, channel_id, queryString)
-		req, err := http.NewRequest("GET", url, nil)
+		req, channel_id, err := messagesRequest(args, client.Now())
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to create request", err), nil
+			return mcp.NewToolResultError(err.Error()), nil
 		}
-		// Set authentication based on auth type
-		if cfg.BasicAuth != "" {
-			req.Header.Set("Authorization", fmt.Sprintf("Basic %s", cfg.BasicAuth))
-		}
-		req.Header.Set("Accept", "application/json")
-
-		resp, err := http.DefaultClient.Do(req)
+		cursor, maxPages, err := ably.PaginationArgs(args)
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Request failed", err), nil
+			return mcp.NewToolResultError(err.Error()), nil
 		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
+		result, nextCursor, err := ably.Paginate[models.Message](ctx, client, req, cursor, maxPages)
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to read response body", err), nil
+			return ably.ErrorResult(err), nil
 		}
+		items := timedMessages(result, client.CipherKey(channel_id))
 
-		if resp.StatusCode >= 400 {
-			return mcp.NewToolResultError(fmt.Sprintf("API error: %s", body)), nil
-		}
-		// Use properly typed response
-		var result map[string]interface{}
-		if err := json.Unmarshal(body, &result); err != nil {
-			// Fallback to raw text if unmarshaling fails
-			return mcp.NewToolResultText(string(body)), nil
-		}
+		return ably.ToolPageResult(items, nextCursor)
+	}
+}
 
-		prettyJSON, err := json.MarshalIndent(result, "", "  ")
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
-		}
+// messagesRequest builds the history request for the channel_id, limit,
+// direction, start and end arguments, relative times being relative to now,
+// and returns it with the channel.
+func messagesRequest(args map[string]any, now time.Time) (*ably.Request, string, error) {
+	channel_idVal, ok := args["channel_id"]
+	if !ok {
+		return nil, "", fmt.Errorf("Missing required path parameter: channel_id")
+	}
+	channel_id, ok := channel_idVal.(string)
+	if !ok {
+		return nil, "", fmt.Errorf("Invalid path parameter: channel_id")
+	}
+	query := url.Values{}
+	if val, ok := args["limit"]; ok {
+		query.Set("limit", ably.FormatParam(val))
+	}
+	if val, ok := args["direction"]; ok {
+		query.Set("direction", ably.FormatParam(val))
+	}
+	if err := ably.TimeRangeArgs(args, query, now); err != nil {
+		return nil, "", err
+	}
+	return &ably.Request{
+		Method: http.MethodGet,
+		Path:   ably.Path("channels", channel_id, "messages"),
+		Query:  query,
+	}, channel_id, nil
+}
 
-		return mcp.NewToolResultText(string(prettyJSON)), nil
+// timedMessages decodes messages read from history, decrypting them when
+// key is set, and adds their RFC 3339 times.
+func timedMessages(messages []models.Message, key []byte) []timedMessage {
+	items := make([]timedMessage, len(messages))
+	for i := range messages {
+		ably.DecodeMessage(&messages[i], key)
+		items[i] = timedMessage{Message: messages[i], Time: ably.FormatTimestamp(messages[i].Timestamp)}
 	}
+	return items
+}
+
+// timedMessage is a message with its timestamp rendered as an RFC 3339 time.
+type timedMessage struct {
+	models.Message
+	Time string `json:"time,omitempty"`
 }
 
 func CreateGetmessagesbychannelTool(cfg *config.APIConfig) models.Tool {
 	tool := mcp.NewTool("get_channels_channel_id_messages",
 		mcp.WithDescription("Get message history for a channel"),
 		mcp.WithString("channel_id", mcp.Required(), mcp.Description("The [Channel's ID](https://www.ably.io/documentation/rest/channels).")),
-		mcp.WithString("start", mcp.Description("")),
+		mcp.WithString("start", mcp.Description("Start of the time range: epoch milliseconds, an RFC 3339 time such as 2024-05-01T12:00:00Z, or a relative time such as -15m, -2h, -7d, today or yesterday.")),
 		mcp.WithNumber("limit", mcp.Description("")),
-		mcp.WithString("end", mcp.Description("")),
+		mcp.WithString("end", mcp.Description("End of the time range, in the same forms as start. Defaults to now.")),
 		mcp.WithString("direction", mcp.Description("")),
+		mcp.WithString("cursor", mcp.Description("Opaque cursor returned as next_cursor by a previous call, to fetch the following page.")),
+		mcp.WithNumber("max_pages", mcp.Description("Number of pages to fetch and concatenate by following next links. Defaults to 1, at most 100.")),
 	)
 
 	return models.Tool{
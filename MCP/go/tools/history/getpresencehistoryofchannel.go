@@ -2,18 +2,17 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
-	"strings"
+	"net/url"
 
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
 	"github.com/platform-api/mcp-server/config"
 	"github.com/platform-api/mcp-server/models"
-	"github.com/mark3labs/mcp-go/mcp"
 )
 
 func GetpresencehistoryofchannelHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		args, ok := request.Params.Arguments.(map[string]any)
 		if !ok {
@@ -27,72 +26,54 @@ func GetpresencehistoryofchannelHandler(cfg *config.APIConfig) func(ctx context.
 		if !ok {
 			return mcp.NewToolResultError("Invalid path parameter: channel_id"), nil
 		}
-		queryParams := make([]string, 0)
-		if val, ok := args["start"]; ok {
-			queryParams = append(queryParams, fmt.Sprintf("start=%v", val))
-		}
+		query := url.Values{}
 		if val, ok := args["limit"]; ok {
-			queryParams = append(queryParams, fmt.Sprintf("limit=%v", val))
-		}
-		if val, ok := args["end"]; ok {
-			queryParams = append(queryParams, fmt.Sprintf("end=%v", val))
+			query.Set("limit", ably.FormatParam(val))
 		}
 		if val, ok := args["direction"]; ok {
-			queryParams = append(queryParams, fmt.Sprintf("direction=%v", val))
+			query.Set("direction", ably.FormatParam(val))
 		}
-		queryString := ""
-		if len(queryParams) > 0 {
-			queryString = "?" + strings.Join(queryParams, "&")
+		if err := ably.TimeRangeArgs(args, query, client.Now()); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
 		}
-		url := fmt.Sprintf("%s/channels/%s/presence/history%s", cfg.BaseURL, channel_id, queryString)
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to create request", err), nil
-		}
-		// Set authentication based on auth type
-		if cfg.BasicAuth != "" {
-			req.Header.Set("Authorization", fmt.Sprintf("Basic %s", cfg.BasicAuth))
-		}
-		req.Header.Set("Accept", "application/json")
-
-		resp, err := http.DefaultClient.Do(req)
+		cursor, maxPages, err := ably.PaginationArgs(args)
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Request failed", err), nil
+			return mcp.NewToolResultError(err.Error()), nil
 		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
+		result, nextCursor, err := ably.Paginate[models.PresenceMessage](ctx, client, &ably.Request{
+			Method: http.MethodGet,
+			Path:   ably.Path("channels", channel_id, "presence", "history"),
+			Query:  query,
+		}, cursor, maxPages)
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to read response body", err), nil
-		}
-
-		if resp.StatusCode >= 400 {
-			return mcp.NewToolResultError(fmt.Sprintf("API error: %s", body)), nil
-		}
-		// Use properly typed response
-		var result models.Error
-		if err := json.Unmarshal(body, &result); err != nil {
-			// Fallback to raw text if unmarshaling fails
-			return mcp.NewToolResultText(string(body)), nil
+			return ably.ErrorResult(err), nil
 		}
-
-		prettyJSON, err := json.MarshalIndent(result, "", "  ")
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		items := make([]timedPresenceMessage, len(result))
+		for i := range result {
+			ably.DecodePresenceMessage(&result[i], client.CipherKey(channel_id))
+			items[i] = timedPresenceMessage{PresenceMessage: result[i], Time: ably.FormatTimestamp(result[i].Timestamp)}
 		}
 
-		return mcp.NewToolResultText(string(prettyJSON)), nil
+		return ably.ToolPageResult(items, nextCursor)
 	}
 }
 
+// timedPresenceMessage is a presence message with its timestamp rendered as an RFC 3339 time.
+type timedPresenceMessage struct {
+	models.PresenceMessage
+	Time string `json:"time,omitempty"`
+}
+
 func CreateGetpresencehistoryofchannelTool(cfg *config.APIConfig) models.Tool {
 	tool := mcp.NewTool("get_channels_channel_id_presence_history",
 		mcp.WithDescription("Get presence history of a channel"),
 		mcp.WithString("channel_id", mcp.Required(), mcp.Description("The [Channel's ID](https://www.ably.io/documentation/rest/channels).")),
-		mcp.WithString("start", mcp.Description("")),
+		mcp.WithString("start", mcp.Description("Start of the time range: epoch milliseconds, an RFC 3339 time such as 2024-05-01T12:00:00Z, or a relative time such as -15m, -2h, -7d, today or yesterday.")),
 		mcp.WithNumber("limit", mcp.Description("")),
-		mcp.WithString("end", mcp.Description("")),
+		mcp.WithString("end", mcp.Description("End of the time range, in the same forms as start. Defaults to now.")),
 		mcp.WithString("direction", mcp.Description("")),
+		mcp.WithString("cursor", mcp.Description("Opaque cursor returned as next_cursor by a previous call, to fetch the following page.")),
+		mcp.WithNumber("max_pages", mcp.Description("Number of pages to fetch and concatenate by following next links. Defaults to 1, at most 100.")),
 	)
 
 	return models.Tool{
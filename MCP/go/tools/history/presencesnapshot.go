@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
+	"github.com/platform-api/mcp-server/config"
+	"github.com/platform-api/mcp-server/models"
+	"github.com/platform-api/mcp-server/presence"
+)
+
+// Bounds of the presence history a snapshot replays.
+const (
+	defaultPresenceWindow    = 24 * time.Hour
+	defaultPresenceScanLimit = 10000
+	maxPresenceScanLimit     = 100000
+)
+
+// presenceSnapshotResult is the membership of a channel at a point in time,
+// reconstructed from the presence history since WindowStart.
+type presenceSnapshotResult struct {
+	At          string                    `json:"at"`
+	Since       string                    `json:"since,omitempty"`
+	WindowStart string                    `json:"window_start"`
+	Events      int                       `json:"events"`
+	Members     []presence.Member         `json:"members"`
+	Diff        *presence.Diff            `json:"diff,omitempty"`
+	Sessions    []presence.ClientSessions `json:"sessions"`
+}
+
+func PresencesnapshotHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		channel_idVal, ok := args["channel_id"]
+		if !ok {
+			return mcp.NewToolResultError("Missing required path parameter: channel_id"), nil
+		}
+		channel_id, ok := channel_idVal.(string)
+		if !ok {
+			return mcp.NewToolResultError("Invalid path parameter: channel_id"), nil
+		}
+		now := client.Now()
+		at, err := timeArg(args, "at", now, now)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		since, err := timeArg(args, "since", time.Time{}, now)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		first := at
+		if !since.IsZero() {
+			if since.After(at) {
+				return mcp.NewToolResultError("invalid time range: since is after at"), nil
+			}
+			first = since
+		}
+		from, err := timeArg(args, "from", first.Add(-defaultPresenceWindow), now)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if from.After(first) {
+			return mcp.NewToolResultError("invalid time range: from must not be after since or at"), nil
+		}
+		scanLimit, err := ably.CountArg(args, "scan_limit", defaultPresenceScanLimit, maxPresenceScanLimit)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		events, err := presence.History(ctx, client, channel_id, client.CipherKey(channel_id), from, at, scanLimit)
+		if err != nil {
+			return ably.ErrorResult(err), nil
+		}
+
+		result := presenceSnapshotResult{
+			At:          at.UTC().Format(ably.TimeLayout),
+			WindowStart: from.UTC().Format(ably.TimeLayout),
+			Events:      len(events),
+			Members:     presence.Snapshot(events, at),
+			Sessions:    presence.Sessions(events, from, at),
+		}
+		if !since.IsZero() {
+			diff := presence.Compare(events, since, at)
+			result.Since = since.UTC().Format(ably.TimeLayout)
+			result.Diff = &diff
+		}
+
+		return ably.ToolResult(result)
+	}
+}
+
+// timeArg reads a point in time parsed with ably.ParseTime, defaulting to def.
+func timeArg(args map[string]any, name string, def, now time.Time) (time.Time, error) {
+	val, ok := args[name]
+	if !ok {
+		return def, nil
+	}
+	t, err := ably.ParseTime(val, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid parameter %s: %v", name, err)
+	}
+	return t, nil
+}
+
+func CreatePresencesnapshotTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("get_presence_snapshot",
+		mcp.WithDescription("Reconstruct the presence set of a channel at a point in time by replaying its presence history, optionally diff it with an earlier point in time (who joined, who left, who updated their data), and report the presence sessions of each client. Only history from the start of the window is replayed: members who entered before it are seen only once they update or leave."),
+		mcp.WithString("channel_id", mcp.Required(), mcp.Description("The [Channel's ID](https://www.ably.io/documentation/rest/channels).")),
+		mcp.WithString("at", mcp.Description("Point in time of the snapshot: epoch milliseconds, an RFC 3339 time, or a relative time such as -15m or yesterday. Defaults to now.")),
+		mcp.WithString("since", mcp.Description("Earlier point in time to diff the snapshot with, in the same forms as at.")),
+		mcp.WithString("from", mcp.Description("Start of the presence history replayed, in the same forms as at. Defaults to 24 hours before since, or before at.")),
+		mcp.WithNumber("scan_limit", mcp.Description("Maximum number of presence events to replay. Defaults to 10000, at most 100000.")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    PresencesnapshotHandler(cfg),
+	}
+}
@@ -0,0 +1,297 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
+	"github.com/platform-api/mcp-server/config"
+	"github.com/platform-api/mcp-server/models"
+)
+
+// Bounds of the number of messages a search may scan.
+const (
+	defaultScanLimit  = 1000
+	maxScanLimit      = 10000
+	defaultMaxMatches = 50
+)
+
+func SearchchannelmessagesHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		req, channel_id, err := messagesRequest(args, client.Now())
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		filter, err := newMessageFilter(args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		scanLimit, err := ably.CountArg(args, "scan_limit", defaultScanLimit, maxScanLimit)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		maxMatches, err := ably.CountArg(args, "max_matches", defaultMaxMatches, scanLimit)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if val, ok := args["cursor"]; ok {
+			cursor, ok := val.(string)
+			if !ok {
+				return mcp.NewToolResultError("invalid parameter: cursor must be a string"), nil
+			}
+			if err := req.ApplyCursor(cursor); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+
+		// Pages are always scanned whole, so that the cursor resumes
+		// exactly after the last message scanned
+		result := searchResult{Matches: []timedMessage{}}
+		for result.Scanned < scanLimit && len(result.Matches) < maxMatches {
+			req.Query.Set("limit", strconv.Itoa(min(ably.MaxPageSize, scanLimit-result.Scanned)))
+			resp, err := client.Do(ctx, req)
+			if err != nil {
+				return ably.ErrorResult(err), nil
+			}
+			var page []models.Message
+			if err := resp.Decode(&page); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to decode page %d: %v", result.Pages+1, err)), nil
+			}
+			result.Pages++
+			result.Scanned += len(page)
+			for _, m := range timedMessages(page, client.CipherKey(channel_id)) {
+				if filter.matches(m.Message) {
+					result.Matches = append(result.Matches, m)
+				}
+			}
+
+			result.NextCursor = resp.NextCursor()
+			if result.NextCursor == "" {
+				break
+			}
+			if err := req.ApplyCursor(result.NextCursor); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+		result.BudgetExhausted = result.NextCursor != "" && result.Scanned >= scanLimit
+
+		return ably.ToolResult(result)
+	}
+}
+
+// searchResult holds the messages matching a search. NextCursor resumes the
+// search after the last message scanned.
+type searchResult struct {
+	Matches         []timedMessage `json:"matches"`
+	Scanned         int            `json:"scanned"`
+	Pages           int            `json:"pages"`
+	BudgetExhausted bool           `json:"budget_exhausted"`
+	NextCursor      string         `json:"next_cursor,omitempty"`
+}
+
+// messageFilter selects messages; unset fields match any message.
+type messageFilter struct {
+	name         string
+	clientID     string
+	connectionID string
+	dataPath     []pathStep
+	dataPattern  *regexp.Regexp
+}
+
+func newMessageFilter(args map[string]any) (*messageFilter, error) {
+	f := &messageFilter{}
+	for _, field := range []struct {
+		name string
+		dest *string
+	}{{"name", &f.name}, {"clientId", &f.clientID}, {"connectionId", &f.connectionID}} {
+		if val, ok := args[field.name]; ok {
+			s, ok := val.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid parameter: %s must be a string", field.name)
+			}
+			*field.dest = s
+		}
+	}
+	if val, ok := args["data_path"].(string); ok && val != "" {
+		path, err := parsePath(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameter data_path: %v", err)
+		}
+		f.dataPath = path
+	}
+	if val, ok := args["data_pattern"].(string); ok && val != "" {
+		re, err := regexp.Compile(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameter data_pattern: %v", err)
+		}
+		f.dataPattern = re
+	}
+	return f, nil
+}
+
+// matches reports whether m passes the filter. Without a pattern, a data
+// path matches when it selects any value; with one, when the pattern
+// matches any selected value, or the whole payload without a path.
+func (f *messageFilter) matches(m models.Message) bool {
+	if f.name != "" && m.Name != f.name {
+		return false
+	}
+	if f.clientID != "" && m.Clientid != f.clientID {
+		return false
+	}
+	if f.connectionID != "" && m.Connectionid != f.connectionID {
+		return false
+	}
+	if f.dataPath == nil && f.dataPattern == nil {
+		return true
+	}
+
+	values := []any{m.Data}
+	if f.dataPath != nil {
+		values = selectPath(m.Data, f.dataPath)
+	}
+	if f.dataPattern == nil {
+		return len(values) > 0
+	}
+	for _, v := range values {
+		if f.dataPattern.MatchString(stringify(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+// stringify renders a decoded payload value for pattern matching: strings
+// as they are and anything else as JSON.
+func stringify(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// pathStep is one step of a JSONPath: a member name, an array index, or a
+// wildcard over all members or elements.
+type pathStep struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parsePath parses the JSONPath subset made of $, .name, ['name'], [n],
+// .* and [*], e.g. $.order.items[*].sku.
+func parsePath(path string) ([]pathStep, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(path), "$")
+	if !ok {
+		return nil, fmt.Errorf("path must start with $")
+	}
+	steps := []pathStep{}
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".*"):
+			steps = append(steps, pathStep{wildcard: true})
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "."):
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty member name")
+			}
+			steps = append(steps, pathStep{name: rest[1 : end+1]})
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [")
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			switch {
+			case inner == "*":
+				steps = append(steps, pathStep{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				steps = append(steps, pathStep{name: inner[1 : len(inner)-1]})
+			default:
+				n, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid subscript [%s]", inner)
+				}
+				steps = append(steps, pathStep{index: n, isIndex: true})
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q", rest)
+		}
+	}
+	return steps, nil
+}
+
+// selectPath returns the values path selects from a decoded JSON value.
+func selectPath(v any, path []pathStep) []any {
+	values := []any{v}
+	for _, step := range path {
+		var next []any
+		for _, v := range values {
+			switch v := v.(type) {
+			case map[string]any:
+				if step.wildcard {
+					for _, member := range v {
+						next = append(next, member)
+					}
+				} else if member, ok := v[step.name]; ok && !step.isIndex {
+					next = append(next, member)
+				}
+			case []any:
+				if step.wildcard {
+					next = append(next, v...)
+				} else if step.isIndex {
+					i := step.index
+					if i < 0 {
+						i += len(v)
+					}
+					if i >= 0 && i < len(v) {
+						next = append(next, v[i])
+					}
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
+
+func CreateSearchchannelmessagesTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("search_channel_messages",
+		mcp.WithDescription("Search the message history of a channel. Ably has no server-side filtering, so history is paged through and filtered locally until max_matches messages match or scan_limit messages have been scanned; next_cursor continues the search."),
+		mcp.WithString("channel_id", mcp.Required(), mcp.Description("The [Channel's ID](https://www.ably.io/documentation/rest/channels).")),
+		mcp.WithString("start", mcp.Description("Start of the time range: epoch milliseconds, an RFC 3339 time such as 2024-05-01T12:00:00Z, or a relative time such as -15m, -2h, -7d, today or yesterday.")),
+		mcp.WithString("end", mcp.Description("End of the time range, in the same forms as start. Defaults to now.")),
+		mcp.WithString("direction", mcp.Description("backwards (default, newest first) or forwards.")),
+		mcp.WithString("name", mcp.Description("Only match messages with this event name.")),
+		mcp.WithString("clientId", mcp.Description("Only match messages published by this client ID.")),
+		mcp.WithString("connectionId", mcp.Description("Only match messages published on this connection.")),
+		mcp.WithString("data_path", mcp.Description("JSONPath into the decoded data, e.g. $.order.items[*].sku. Without data_pattern, matches messages where the path selects any value.")),
+		mcp.WithString("data_pattern", mcp.Description("Regular expression matched against the values selected by data_path, or against the whole data. Non-string values are matched in their JSON form.")),
+		mcp.WithNumber("max_matches", mcp.Description("Stop after the page on which this many messages have matched. Defaults to 50.")),
+		mcp.WithNumber("scan_limit", mcp.Description("Maximum number of messages to scan. Defaults to 1000, at most 10000.")),
+		mcp.WithString("cursor", mcp.Description("Opaque cursor returned as next_cursor by a previous search, to continue scanning.")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    SearchchannelmessagesHandler(cfg),
+	}
+}
@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/platform-api/mcp-server/models"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []pathStep
+		err  string
+	}{
+		{path: "$", want: []pathStep{}},
+		{path: " $.order.items[*].sku ", want: []pathStep{{name: "order"}, {name: "items"}, {wildcard: true}, {name: "sku"}}},
+		{path: "$['first name'][\"x.y\"]", want: []pathStep{{name: "first name"}, {name: "x.y"}}},
+		{path: "$.items[0][-1]", want: []pathStep{{name: "items"}, {index: 0, isIndex: true}, {index: -1, isIndex: true}}},
+		{path: "$.*.id", want: []pathStep{{wildcard: true}, {name: "id"}}},
+		{path: "$[ * ]", want: []pathStep{{wildcard: true}}},
+		{path: "order.id", err: "must start with $"},
+		{path: "", err: "must start with $"},
+		{path: "$..x", err: "empty member name"},
+		{path: "$.a.", err: "empty member name"},
+		{path: "$.items[0", err: "unclosed ["},
+		{path: "$[x]", err: "invalid subscript"},
+		{path: "$['x]", err: "invalid subscript"},
+		{path: "$x", err: "unexpected"},
+	}
+	for _, tt := range tests {
+		got, err := parsePath(tt.path)
+		switch {
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("parsePath(%q) error = %v, want %q", tt.path, err, tt.err)
+		case tt.err == "" && err != nil:
+			t.Errorf("parsePath(%q) error = %v", tt.path, err)
+		case tt.err == "" && !reflect.DeepEqual(got, tt.want):
+			t.Errorf("parsePath(%q) = %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+}
+
+// payload decodes a JSON message payload the way it reaches the filter.
+func payload(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestSelectPath(t *testing.T) {
+	data := payload(t, `{
+		"order": {"id": 7, "items": [{"sku": "a", "qty": 1}, {"sku": "b", "qty": 2}, {"sku": "c"}]},
+		"grid": [[1, 2], [3, 4]],
+		"tags": {"x": "red", "y": "blue"}
+	}`)
+	tests := []struct {
+		path string
+		want []any
+	}{
+		{"$.order.id", []any{7.0}},
+		{"$.order.items[*].sku", []any{"a", "b", "c"}},
+		{"$.order.items[*].qty", []any{1.0, 2.0}},
+		{"$.order.items[1]['sku']", []any{"b"}},
+		{"$.order.items[-1].sku", []any{"c"}},
+		{"$.order.items[3].sku", nil},
+		{"$.order.items[-4]", nil},
+		{"$.grid[*][1]", []any{2.0, 4.0}},
+		{"$.grid[1][*]", []any{3.0, 4.0}},
+		{"$.tags.*", []any{"blue", "red"}},
+		{"$.order[0]", nil},
+		{"$.grid.x", nil},
+		{"$.missing.id", nil},
+	}
+	for _, tt := range tests {
+		path, err := parsePath(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := selectPath(data, path)
+		// Members of an object come in no particular order
+		if strings.HasSuffix(tt.path, ".*") {
+			sort.Slice(got, func(i, j int) bool { return stringify(got[i]) < stringify(got[j]) })
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("selectPath(%q) = %#v, want %#v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestMessageFilterMatches(t *testing.T) {
+	order := models.Message{Name: "order", Clientid: "bob", Data: payload(t, `{"total": 42.5, "paid": true, "items": [{"sku": "A-1"}], "note": null}`)}
+	text := models.Message{Name: "chat", Clientid: "alice", Data: "hello world"}
+	tests := []struct {
+		args map[string]any
+		want []bool // for order and text
+	}{
+		{map[string]any{}, []bool{true, true}},
+		{map[string]any{"name": "chat"}, []bool{false, true}},
+		{map[string]any{"clientId": "bob"}, []bool{true, false}},
+		{map[string]any{"data_pattern": "^hello"}, []bool{false, true}},
+		// Without a path, objects are matched as JSON
+		{map[string]any{"data_pattern": `"sku":"A-1"`}, []bool{true, false}},
+		{map[string]any{"data_path": "$.items[0].sku"}, []bool{true, false}},
+		{map[string]any{"data_path": "$.items[0].sku", "data_pattern": "^A-"}, []bool{true, false}},
+		{map[string]any{"data_path": "$.total", "data_pattern": `^42\.5$`}, []bool{true, false}},
+		{map[string]any{"data_path": "$.paid", "data_pattern": "^true$"}, []bool{true, false}},
+		{map[string]any{"data_path": "$.note", "data_pattern": "^null$"}, []bool{true, false}},
+		{map[string]any{"data_path": "$.items", "data_pattern": `^\[\{"sku":"A-1"\}\]$`}, []bool{true, false}},
+		{map[string]any{"data_path": "$.total", "data_pattern": "^43"}, []bool{false, false}},
+		{map[string]any{"data_path": "$.missing"}, []bool{false, false}},
+	}
+	for _, tt := range tests {
+		f, err := newMessageFilter(tt.args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, m := range []models.Message{order, text} {
+			if got := f.matches(m); got != tt.want[i] {
+				t.Errorf("filter %v matches %s = %v, want %v", tt.args, m.Name, got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestNewMessageFilterErrors(t *testing.T) {
+	tests := []struct {
+		args map[string]any
+		err  string
+	}{
+		{map[string]any{"name": 3}, "name must be a string"},
+		{map[string]any{"data_path": "$..x"}, "invalid parameter data_path"},
+		{map[string]any{"data_pattern": "("}, "invalid parameter data_pattern"},
+	}
+	for _, tt := range tests {
+		if _, err := newMessageFilter(tt.args); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("newMessageFilter(%v) error = %v, want %q", tt.args, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"context"
+	"slices"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
+	"github.com/platform-api/mcp-server/config"
+	"github.com/platform-api/mcp-server/models"
+)
+
+func BatchpublishHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		channels, err := request.RequireStringSlice("channels")
+		if err != nil {
+			return mcp.NewToolResultError("Missing required parameter: channels"), nil
+		}
+		if len(channels) == 0 {
+			return mcp.NewToolResultError("At least one channel is required"), nil
+		}
+		messagesVal, ok := args["messages"]
+		if !ok {
+			return mcp.NewToolResultError("Missing required parameter: messages"), nil
+		}
+		messages, err := decodeMessages(messagesVal)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if idempotent, _ := args["idempotent"].(bool); idempotent {
+			if err := ably.AssignIDs(messages); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+		specs, err := batchSpecs(client, channels, messages)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		results, err := client.PublishBatch(ctx, specs)
+		if err != nil {
+			return ably.ErrorResult(err), nil
+		}
+
+		var report batchPublishResult
+		for _, r := range results {
+			result := channelPublishResult{BatchResult: r}
+			if r.Error == nil {
+				result.Messageids = ably.MessageIDs(messages, r.Messageid)
+				report.SuccessCount++
+			} else {
+				report.FailureCount++
+			}
+			report.Results = append(report.Results, result)
+		}
+		result, err := ably.ToolResult(report)
+		// A publish that failed on every channel is reported as a failed call
+		result.IsError = result.IsError || report.SuccessCount == 0
+		return result, err
+	}
+}
+
+// batchSpecs encodes messages for each channel. Channels without a cipher
+// key share one spec, while each encrypted channel needs its own.
+func batchSpecs(client *ably.Client, channels []string, messages []models.Message) ([]ably.BatchSpec, error) {
+	var specs []ably.BatchSpec
+	var plain []string
+	for _, channel := range channels {
+		key := client.CipherKey(channel)
+		if key == nil {
+			plain = append(plain, channel)
+			continue
+		}
+		encrypted := slices.Clone(messages)
+		if err := encodeMessages(encrypted, key); err != nil {
+			return nil, err
+		}
+		if err := ably.ValidateBatch(encrypted); err != nil {
+			return nil, err
+		}
+		specs = append(specs, ably.BatchSpec{Channels: []string{channel}, Messages: encrypted})
+	}
+	if len(plain) > 0 {
+		encoded := slices.Clone(messages)
+		if err := encodeMessages(encoded, nil); err != nil {
+			return nil, err
+		}
+		if err := ably.ValidateBatch(encoded); err != nil {
+			return nil, err
+		}
+		specs = append(specs, ably.BatchSpec{Channels: plain, Messages: encoded})
+	}
+	return specs, nil
+}
+
+// batchPublishResult reports the outcome of a batch publish per channel.
+type batchPublishResult struct {
+	SuccessCount int                    `json:"successCount"`
+	FailureCount int                    `json:"failureCount"`
+	Results      []channelPublishResult `json:"results"`
+}
+
+// channelPublishResult is the outcome on one channel, with the IDs of the
+// published messages when it succeeded.
+type channelPublishResult struct {
+	ably.BatchResult
+	Messageids []string `json:"messageIds,omitempty"`
+}
+
+func CreateBatchpublishTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("batch_publish",
+		mcp.WithDescription("Publish the same messages to several channels in one request using Ably's batch publish endpoint. Returns a per-channel report; publishing can succeed on some channels and fail on others."),
+		mcp.WithArray("channels", mcp.Required(), mcp.Description("Names of the channels to publish to."), mcp.WithStringItems()),
+		mcp.WithArray("messages", mcp.Required(), mcp.Description("Messages to publish to every channel, in order."), mcp.Items(messageSchema)),
+		mcp.WithBoolean("idempotent", mcp.Description(idempotentDescription)),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    BatchpublishHandler(cfg),
+	}
+}
@@ -4,16 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"bytes"
 
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
 	"github.com/platform-api/mcp-server/config"
 	"github.com/platform-api/mcp-server/models"
-	"github.com/mark3labs/mcp-go/mcp"
 )
 
 func PublishmessagestochannelHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		args, ok := request.Params.Arguments.(map[string]any)
 		if !ok {
@@ -27,76 +27,147 @@ func PublishmessagestochannelHandler(cfg *config.APIConfig) func(ctx context.Con
 		if !ok {
 			return mcp.NewToolResultError("Invalid path parameter: channel_id"), nil
 		}
-		// Create properly typed request body using the generated schema
-		var requestBody models.Message
-		
-		// Optimized: Single marshal/unmarshal with JSON tags handling field mapping
-		if argsJSON, err := json.Marshal(args); err == nil {
-			if err := json.Unmarshal(argsJSON, &requestBody); err != nil {
-				return mcp.NewToolResultError(fmt.Sprintf("Failed to convert arguments to request type: %v", err)), nil
-			}
-		} else {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal arguments: %v", err)), nil
-		}
-		
-		bodyBytes, err := json.Marshal(requestBody)
+		messages, err := publishMessages(args)
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to encode request body", err), nil
+			return mcp.NewToolResultError(err.Error()), nil
 		}
-		url := fmt.Sprintf("%s/channels/%s/messages", cfg.BaseURL, channel_id)
-		req, err := http.NewRequest("POST", url, bytes.NewBuffer(bodyBytes))
-		req.Header.Set("Content-Type", "application/json")
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to create request", err), nil
+		if idempotent, _ := args["idempotent"].(bool); idempotent {
+			if err := ably.AssignIDs(messages); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
 		}
-		// Set authentication based on auth type
-		if cfg.BasicAuth != "" {
-			req.Header.Set("Authorization", fmt.Sprintf("Basic %s", cfg.BasicAuth))
+		if err := encodeMessages(messages, client.CipherKey(channel_id)); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
 		}
-		req.Header.Set("Accept", "application/json")
-
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Request failed", err), nil
+		if err := ably.ValidateBatch(messages); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
 		}
-		defer resp.Body.Close()
 
-		body, err := io.ReadAll(resp.Body)
+		// All messages are sent in one request, so they are published atomically
+		resp, err := client.Do(ctx, &ably.Request{
+			Method: http.MethodPost,
+			Path:   ably.Path("channels", channel_id, "messages"),
+			Body:   messages,
+			// Message IDs make the publish idempotent, so it is safe to retry
+			Idempotent: ably.HasIDs(messages),
+		})
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to read response body", err), nil
-		}
-
-		if resp.StatusCode >= 400 {
-			return mcp.NewToolResultError(fmt.Sprintf("API error: %s", body)), nil
+			return ably.ErrorResult(err), nil
 		}
 		// Use properly typed response
-		var result models.Error
-		if err := json.Unmarshal(body, &result); err != nil {
+		var response models.PublishResponse
+		if err := resp.Decode(&response); err != nil {
 			// Fallback to raw text if unmarshaling fails
-			return mcp.NewToolResultText(string(body)), nil
+			return mcp.NewToolResultText(string(resp.Body)), nil
+		}
+		result := publishResult{
+			PublishResponse: response,
+			Messageids:      ably.MessageIDs(messages, response.Messageid),
 		}
 
-		prettyJSON, err := json.MarshalIndent(result, "", "  ")
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		return ably.ToolResult(result)
+	}
+}
+
+// publishResult is a publish response with the ID of every published message.
+type publishResult struct {
+	models.PublishResponse
+	Messageids []string `json:"messageIds"`
+}
+
+// messageFields are the arguments describing a single message.
+var messageFields = []string{"timestamp", "clientId", "connectionId", "data", "encoding", "extras", "id", "name"}
+
+const (
+	dataDescription       = "The payload: a string, or any other JSON value, which is published with the json encoding. Binary data is given as a base64 string with encoding base64."
+	encodingDescription   = "The encoding of a string payload, e.g. base64 for binary data or utf-8/base64 for base64 encoded text. Leave empty for plain strings and JSON values."
+	idempotentDescription = "Generate idempotent message IDs (a random base ID plus the index of each message), so that retrying the publish cannot publish the messages twice. Messages must not have an id of their own."
+)
+
+// messageSchema is the JSON schema of a message in a messages argument.
+var messageSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"name":     map[string]any{"type": "string", "description": "The event name, if provided."},
+		"data":     map[string]any{"description": dataDescription},
+		"encoding": map[string]any{"type": "string", "description": encodingDescription},
+		"id":       map[string]any{"type": "string", "description": "A Unique ID for idempotent publishing."},
+		"clientId": map[string]any{"type": "string", "description": "The client ID of the publisher of this message."},
+		"extras":   map[string]any{"type": "object", "description": "Extras object. Currently only allows for push extra."},
+	},
+}
+
+// publishMessages returns the messages to publish: the messages argument,
+// or a single message built from the top-level message fields.
+func publishMessages(args map[string]any) ([]models.Message, error) {
+	if val, ok := args["messages"]; ok {
+		for _, field := range messageFields {
+			if _, ok := args[field]; ok {
+				return nil, fmt.Errorf("Parameter %s cannot be combined with messages; set it on each message instead", field)
+			}
+		}
+		return decodeMessages(val)
+	}
+
+	// Create properly typed request body using the generated schema
+	var message models.Message
+
+	// Optimized: Single marshal/unmarshal with JSON tags handling field mapping
+	argsJSON, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to marshal arguments: %v", err)
+	}
+	if err := json.Unmarshal(argsJSON, &message); err != nil {
+		return nil, fmt.Errorf("Failed to convert arguments to request type: %v", err)
+	}
+	return []models.Message{message}, nil
+}
+
+// decodeMessages converts a messages argument to typed messages.
+func decodeMessages(val any) ([]models.Message, error) {
+	var messages []models.Message
+	argsJSON, err := json.Marshal(val)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to marshal arguments: %v", err)
+	}
+	if err := json.Unmarshal(argsJSON, &messages); err != nil {
+		return nil, fmt.Errorf("Failed to convert messages to request type: %v", err)
+	}
+	return messages, nil
+}
+
+// encodeMessages prepares the payloads of messages for publishing,
+// encrypting them when key is set.
+func encodeMessages(messages []models.Message, key []byte) error {
+	for i := range messages {
+		if err := ably.EncodeMessage(&messages[i], key); err != nil {
+			return fmt.Errorf("Invalid message %d: %v", i, err)
 		}
+	}
+	return nil
+}
 
-		return mcp.NewToolResultText(string(prettyJSON)), nil
+// withData adds a data parameter, which accepts a value of any JSON type.
+func withData(description string) mcp.ToolOption {
+	return func(t *mcp.Tool) {
+		t.InputSchema.Properties["data"] = map[string]any{"description": description}
 	}
 }
 
 func CreatePublishmessagestochannelTool(cfg *config.APIConfig) models.Tool {
 	tool := mcp.NewTool("post_channels_channel_id_messages",
-		mcp.WithDescription("Publish a message to a channel"),
+		mcp.WithDescription("Publish one or more messages to a channel. Either set the message fields directly, or pass messages to publish a batch atomically in one request; the IDs of all published messages are returned."),
 		mcp.WithString("channel_id", mcp.Required(), mcp.Description("The [Channel's ID](https://www.ably.io/documentation/rest/channels).")),
 		mcp.WithNumber("timestamp", mcp.Description("Input parameter: Timestamp when the message was received by the Ably, as milliseconds since the epoch.")),
 		mcp.WithString("clientId", mcp.Description("Input parameter: The [client ID](https://www.ably.io/documentation/core-features/authentication#identified-clients) of the publisher of this message.")),
 		mcp.WithString("connectionId", mcp.Description("Input parameter: The connection ID of the publisher of this message.")),
-		mcp.WithString("data", mcp.Description("Input parameter: The string encoded payload, with the encoding specified below.")),
-		mcp.WithString("encoding", mcp.Description("Input parameter: This will typically be empty as all messages received from Ably are automatically decoded client-side using this value. However, if the message encoding cannot be processed, this attribute will contain the remaining transformations not applied to the data payload.")),
+		withData(dataDescription),
+		mcp.WithString("encoding", mcp.Description(encodingDescription)),
 		mcp.WithObject("extras", mcp.Description("Input parameter: Extras object. Currently only allows for [push](https://www.ably.io/documentation/general/push/publish#channel-broadcast-example) extra.")),
 		mcp.WithString("id", mcp.Description("Input parameter: A Unique ID that can be specified by the publisher for [idempotent publishing](https://www.ably.io/documentation/rest/messages#idempotent).")),
 		mcp.WithString("name", mcp.Description("Input parameter: The event name, if provided.")),
+		mcp.WithArray("messages", mcp.Description("Messages to publish together, in order. Cannot be combined with the single message fields."), mcp.Items(messageSchema)),
+		mcp.WithBoolean("idempotent", mcp.Description(idempotentDescription)),
 	)
 
 	return models.Tool{
@@ -2,75 +2,41 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
-	"strings"
+	"net/url"
 
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
 	"github.com/platform-api/mcp-server/config"
 	"github.com/platform-api/mcp-server/models"
-	"github.com/mark3labs/mcp-go/mcp"
 )
 
 func DeletepushdevicedetailsHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		args, ok := request.Params.Arguments.(map[string]any)
 		if !ok {
 			return mcp.NewToolResultError("Invalid arguments object"), nil
 		}
-		queryParams := make([]string, 0)
+		query := url.Values{}
 		if val, ok := args["channel"]; ok {
-			queryParams = append(queryParams, fmt.Sprintf("channel=%v", val))
+			query.Set("channel", ably.FormatParam(val))
 		}
 		if val, ok := args["deviceId"]; ok {
-			queryParams = append(queryParams, fmt.Sprintf("deviceId=%v", val))
+			query.Set("deviceId", ably.FormatParam(val))
 		}
 		if val, ok := args["clientId"]; ok {
-			queryParams = append(queryParams, fmt.Sprintf("clientId=%v", val))
-		}
-		queryString := ""
-		if len(queryParams) > 0 {
-			queryString = "?" + strings.Join(queryParams, "&")
+			query.Set("clientId", ably.FormatParam(val))
 		}
-		url := fmt.Sprintf("%s/push/channelSubscriptions%s", cfg.BaseURL, queryString)
-		req, err := http.NewRequest("DELETE", url, nil)
+		resp, err := client.Do(ctx, &ably.Request{
+			Method: http.MethodDelete,
+			Path:   ably.Path("push", "channelSubscriptions"),
+			Query:  query,
+		})
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to create request", err), nil
+			return ably.ErrorResult(err), nil
 		}
-		// Set authentication based on auth type
-		if cfg.BasicAuth != "" {
-			req.Header.Set("Authorization", fmt.Sprintf("Basic %s", cfg.BasicAuth))
-		}
-		req.Header.Set("Accept", "application/json")
-
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Request failed", err), nil
-		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to read response body", err), nil
-		}
-
-		if resp.StatusCode >= 400 {
-			return mcp.NewToolResultError(fmt.Sprintf("API error: %s", body)), nil
-		}
-		// Use properly typed response
-		var result models.Error
-		if err := json.Unmarshal(body, &result); err != nil {
-			// Fallback to raw text if unmarshaling fails
-			return mcp.NewToolResultText(string(body)), nil
-		}
-
-		prettyJSON, err := json.MarshalIndent(result, "", "  ")
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
-		}
-
-		return mcp.NewToolResultText(string(prettyJSON)), nil
+		return ably.ToolStatusResult(resp)
 	}
 }
 
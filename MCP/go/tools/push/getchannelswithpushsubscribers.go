@@ -2,62 +2,42 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
 	"github.com/platform-api/mcp-server/config"
 	"github.com/platform-api/mcp-server/models"
-	"github.com/mark3labs/mcp-go/mcp"
 )
 
 func GetchannelswithpushsubscribersHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		url := fmt.Sprintf("%s/push/channels", cfg.BaseURL)
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to create request", err), nil
-		}
-		// Set authentication based on auth type
-		if cfg.BasicAuth != "" {
-			req.Header.Set("Authorization", fmt.Sprintf("Basic %s", cfg.BasicAuth))
-		}
-		req.Header.Set("Accept", "application/json")
-
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Request failed", err), nil
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
 		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
+		cursor, maxPages, err := ably.PaginationArgs(args)
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to read response body", err), nil
-		}
-
-		if resp.StatusCode >= 400 {
-			return mcp.NewToolResultError(fmt.Sprintf("API error: %s", body)), nil
-		}
-		// Use properly typed response
-		var result models.Error
-		if err := json.Unmarshal(body, &result); err != nil {
-			// Fallback to raw text if unmarshaling fails
-			return mcp.NewToolResultText(string(body)), nil
+			return mcp.NewToolResultError(err.Error()), nil
 		}
-
-		prettyJSON, err := json.MarshalIndent(result, "", "  ")
+		result, nextCursor, err := ably.Paginate[string](ctx, client, &ably.Request{
+			Method: http.MethodGet,
+			Path:   ably.Path("push", "channels"),
+		}, cursor, maxPages)
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+			return ably.ErrorResult(err), nil
 		}
 
-		return mcp.NewToolResultText(string(prettyJSON)), nil
+		return ably.ToolPageResult(result, nextCursor)
 	}
 }
 
 func CreateGetchannelswithpushsubscribersTool(cfg *config.APIConfig) models.Tool {
 	tool := mcp.NewTool("get_push_channels",
 		mcp.WithDescription("List all channels with at least one subscribed device"),
+		mcp.WithString("cursor", mcp.Description("Opaque cursor returned as next_cursor by a previous call, to fetch the following page.")),
+		mcp.WithNumber("max_pages", mcp.Description("Number of pages to fetch and concatenate by following next links. Defaults to 1, at most 100.")),
 	)
 
 	return models.Tool{
@@ -4,16 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"bytes"
 
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
 	"github.com/platform-api/mcp-server/config"
 	"github.com/platform-api/mcp-server/models"
-	"github.com/mark3labs/mcp-go/mcp"
 )
 
 func PatchpushdevicedetailsHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		args, ok := request.Params.Arguments.(map[string]any)
 		if !ok {
@@ -29,7 +29,7 @@ func PatchpushdevicedetailsHandler(cfg *config.APIConfig) func(ctx context.Conte
 		}
 		// Create properly typed request body using the generated schema
 		var requestBody models.DeviceDetails
-		
+
 		// Optimized: Single marshal/unmarshal with JSON tags handling field mapping
 		if argsJSON, err := json.Marshal(args); err == nil {
 			if err := json.Unmarshal(argsJSON, &requestBody); err != nil {
@@ -38,50 +38,23 @@ func PatchpushdevicedetailsHandler(cfg *config.APIConfig) func(ctx context.Conte
 		} else {
 			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal arguments: %v", err)), nil
 		}
-		
-		bodyBytes, err := json.Marshal(requestBody)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to encode request body", err), nil
-		}
-		url := fmt.Sprintf("%s/push/deviceRegistrations/%s", cfg.BaseURL, device_id)
-		req, err := http.NewRequest("PATCH", url, bytes.NewBuffer(bodyBytes))
-		req.Header.Set("Content-Type", "application/json")
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to create request", err), nil
-		}
-		// Set authentication based on auth type
-		if cfg.BasicAuth != "" {
-			req.Header.Set("Authorization", fmt.Sprintf("Basic %s", cfg.BasicAuth))
-		}
-		req.Header.Set("Accept", "application/json")
 
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := client.Do(ctx, &ably.Request{
+			Method: http.MethodPatch,
+			Path:   ably.Path("push", "deviceRegistrations", device_id),
+			Body:   requestBody,
+		})
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Request failed", err), nil
-		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to read response body", err), nil
-		}
-
-		if resp.StatusCode >= 400 {
-			return mcp.NewToolResultError(fmt.Sprintf("API error: %s", body)), nil
+			return ably.ErrorResult(err), nil
 		}
 		// Use properly typed response
-		var result models.Error
-		if err := json.Unmarshal(body, &result); err != nil {
+		var result models.DeviceDetails
+		if err := resp.Decode(&result); err != nil {
 			// Fallback to raw text if unmarshaling fails
-			return mcp.NewToolResultText(string(body)), nil
-		}
-
-		prettyJSON, err := json.MarshalIndent(result, "", "  ")
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+			return mcp.NewToolResultText(string(resp.Body)), nil
 		}
 
-		return mcp.NewToolResultText(string(prettyJSON)), nil
+		return ably.ToolResult(result)
 	}
 }
 
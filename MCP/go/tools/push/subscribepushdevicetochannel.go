@@ -4,24 +4,24 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"bytes"
 
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
 	"github.com/platform-api/mcp-server/config"
 	"github.com/platform-api/mcp-server/models"
-	"github.com/mark3labs/mcp-go/mcp"
 )
 
 func SubscribepushdevicetochannelHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		args, ok := request.Params.Arguments.(map[string]any)
 		if !ok {
 			return mcp.NewToolResultError("Invalid arguments object"), nil
 		}
 		// Create properly typed request body using the generated schema
-		var requestBody interface{}
-		
+		var requestBody models.ChannelSubscription
+
 		// Optimized: Single marshal/unmarshal with JSON tags handling field mapping
 		if argsJSON, err := json.Marshal(args); err == nil {
 			if err := json.Unmarshal(argsJSON, &requestBody); err != nil {
@@ -30,56 +30,41 @@ func SubscribepushdevicetochannelHandler(cfg *config.APIConfig) func(ctx context
 		} else {
 			return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal arguments: %v", err)), nil
 		}
-		
-		bodyBytes, err := json.Marshal(requestBody)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to encode request body", err), nil
+		if requestBody.Channel == "" {
+			return mcp.NewToolResultError("Missing required parameter: channel"), nil
 		}
-		url := fmt.Sprintf("%s/push/channelSubscriptions", cfg.BaseURL)
-		req, err := http.NewRequest("POST", url, bytes.NewBuffer(bodyBytes))
-		req.Header.Set("Content-Type", "application/json")
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to create request", err), nil
-		}
-		// Set authentication based on auth type
-		if cfg.BasicAuth != "" {
-			req.Header.Set("Authorization", fmt.Sprintf("Basic %s", cfg.BasicAuth))
+		if (requestBody.Deviceid == "") == (requestBody.Clientid == "") {
+			return mcp.NewToolResultError("Exactly one of deviceId or clientId must be set"), nil
 		}
-		req.Header.Set("Accept", "application/json")
 
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := client.Do(ctx, &ably.Request{
+			Method: http.MethodPost,
+			Path:   ably.Path("push", "channelSubscriptions"),
+			Body:   requestBody,
+		})
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Request failed", err), nil
+			return ably.ErrorResult(err), nil
 		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to read response body", err), nil
-		}
-
-		if resp.StatusCode >= 400 {
-			return mcp.NewToolResultError(fmt.Sprintf("API error: %s", body)), nil
+		if len(resp.Body) == 0 {
+			return ably.ToolStatusResult(resp)
 		}
 		// Use properly typed response
-		var result models.Error
-		if err := json.Unmarshal(body, &result); err != nil {
+		var result models.ChannelSubscription
+		if err := resp.Decode(&result); err != nil {
 			// Fallback to raw text if unmarshaling fails
-			return mcp.NewToolResultText(string(body)), nil
-		}
-
-		prettyJSON, err := json.MarshalIndent(result, "", "  ")
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+			return mcp.NewToolResultText(string(resp.Body)), nil
 		}
 
-		return mcp.NewToolResultText(string(prettyJSON)), nil
+		return ably.ToolResult(result)
 	}
 }
 
 func CreateSubscribepushdevicetochannelTool(cfg *config.APIConfig) models.Tool {
 	tool := mcp.NewTool("post_push_channelSubscriptions",
 		mcp.WithDescription("Subscribe a device to a channel"),
+		mcp.WithString("channel", mcp.Required(), mcp.Description("Input parameter: Channel name.")),
+		mcp.WithString("deviceId", mcp.Description("Input parameter: Must be set when clientId is empty, cannot be used with clientId.")),
+		mcp.WithString("clientId", mcp.Description("Input parameter: Must be set when deviceId is empty, cannot be used with deviceId.")),
 	)
 
 	return models.Tool{
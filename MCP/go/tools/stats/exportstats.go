@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
+	"github.com/platform-api/mcp-server/config"
+	"github.com/platform-api/mcp-server/models"
+	"github.com/platform-api/mcp-server/stats"
+)
+
+func ExportstatsHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		export, err := stats.ExportArgs(args, client.Now())
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		var out strings.Builder
+		if err := export.Run(ctx, client, &out); err != nil {
+			return ably.ErrorResult(err), nil
+		}
+
+		return mcp.NewToolResultText(out.String()), nil
+	}
+}
+
+func CreateExportstatsTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("export_stats",
+		mcp.WithDescription("Export application stats as CSV, JSON Lines or Prometheus text exposition, one row per interval, grouped by unit. Intervals without activity are included as zeros. The Prometheus exposition is meant to be scraped: it holds only the latest complete interval of each unit, without timestamps. The same export is served over HTTP at /stats/export."),
+		mcp.WithString("format", mcp.Description("csv (default), jsonl or prometheus (latest complete interval of each unit only).")),
+		mcp.WithString("start", mcp.Description(startDescription)),
+		mcp.WithString("end", mcp.Description(endDescription)),
+		mcp.WithString("unit", mcp.Description("Unit of the stats intervals: minute, hour (default), day or month, or several separated by commas, e.g. hour,day.")),
+		mcp.WithArray("metrics", mcp.Description("Metrics to export, as aliases such as messages or connections, or counter paths such as inbound.realtime.messages.count. Defaults to every counter."), mcp.WithStringItems()),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    ExportstatsHandler(cfg),
+	}
+}
@@ -2,62 +2,51 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
+	"time"
 
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
 	"github.com/platform-api/mcp-server/config"
 	"github.com/platform-api/mcp-server/models"
-	"github.com/mark3labs/mcp-go/mcp"
 )
 
+// timeResult is Ably's time and how the local clock compares to it.
+type timeResult struct {
+	Timestamp     int64  `json:"timestamp"` // Milliseconds since the epoch.
+	Time          string `json:"time"`
+	LocalTime     string `json:"local_time"`
+	OffsetMs      int64  `json:"offset_ms"` // Added to the local time, gives Ably's time.
+	RoundTripMs   int64  `json:"round_trip_ms"`
+	OffsetApplied bool   `json:"offset_applied"` // Whether timestamps sent to Ably are corrected by the offset.
+}
+
 func GettimeHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		url := fmt.Sprintf("%s/time", cfg.BaseURL)
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to create request", err), nil
-		}
-		// Set authentication based on auth type
-		if cfg.BasicAuth != "" {
-			req.Header.Set("Authorization", fmt.Sprintf("Basic %s", cfg.BasicAuth))
-		}
-		req.Header.Set("Accept", "application/json")
-
-		resp, err := http.DefaultClient.Do(req)
+		sample, err := client.MeasureClock(ctx)
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Request failed", err), nil
+			return ably.ErrorResult(err), nil
 		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to read response body", err), nil
-		}
-
-		if resp.StatusCode >= 400 {
-			return mcp.NewToolResultError(fmt.Sprintf("API error: %s", body)), nil
-		}
-		// Use properly typed response
-		var result models.Error
-		if err := json.Unmarshal(body, &result); err != nil {
-			// Fallback to raw text if unmarshaling fails
-			return mcp.NewToolResultText(string(body)), nil
-		}
-
-		prettyJSON, err := json.MarshalIndent(result, "", "  ")
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+		// With the skew check enabled, every measurement refreshes the
+		// correction for this endpoint
+		if cfg.ClockSkewCheck {
+			client.SetClockOffset(sample.Offset)
 		}
 
-		return mcp.NewToolResultText(string(prettyJSON)), nil
+		return ably.ToolResult(timeResult{
+			Timestamp:     sample.ServerTime.UnixMilli(),
+			Time:          ably.FormatTimestamp(sample.ServerTime.UnixMilli()),
+			LocalTime:     time.Now().UTC().Format(ably.TimeLayout),
+			OffsetMs:      sample.Offset.Milliseconds(),
+			RoundTripMs:   sample.RoundTrip.Milliseconds(),
+			OffsetApplied: cfg.ClockSkewCheck,
+		})
 	}
 }
 
 func CreateGettimeTool(cfg *config.APIConfig) models.Tool {
 	tool := mcp.NewTool("get_time",
-		mcp.WithDescription("Get the service time"),
+		mcp.WithDescription("Get the service time, with the estimated offset of the local clock from it and the round trip time. A large offset makes Ably reject token requests signed here; with CLOCK_SKEW_CHECK enabled the offset is applied to the timestamps this server sends."),
 	)
 
 	return models.Tool{
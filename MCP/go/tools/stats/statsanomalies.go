@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
+	"github.com/platform-api/mcp-server/config"
+	"github.com/platform-api/mcp-server/models"
+	"github.com/platform-api/mcp-server/stats"
+)
+
+// Defaults of the anomaly detection parameters.
+const (
+	defaultBaseline  = 24
+	defaultThreshold = 3
+)
+
+// anomaliesResult is the result of stats_anomalies.
+type anomaliesResult struct {
+	Unit      string          `json:"unit"`
+	Start     string          `json:"start"`
+	End       string          `json:"end"`
+	Baseline  int             `json:"baseline_intervals"`
+	Threshold float64         `json:"threshold"`
+	Anomalies []stats.Anomaly `json:"anomalies"`
+}
+
+func StatsanomaliesHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		unit, err := stats.UnitArg(args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		start, end, err := stats.WindowArgs(args, "start", "end", unit, client.Now())
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		metrics, err := stats.MetricsArg(args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		baseline := defaultBaseline
+		if val, ok := args["baseline"]; ok {
+			n, ok := val.(float64)
+			if !ok || n < stats.MinBaseline || n != float64(int(n)) {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid parameter: baseline must be an integer of at least %d", stats.MinBaseline)), nil
+			}
+			baseline = int(n)
+		}
+		threshold := float64(defaultThreshold)
+		if val, ok := args["threshold"]; ok {
+			if threshold, ok = val.(float64); !ok || threshold <= 0 {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid parameter: threshold must be a positive number, got %v", val)), nil
+			}
+		}
+
+		// The intervals before start are fetched too, as the baseline of the first ones
+		from := stats.Truncate(start, unit)
+		intervals, err := stats.Fetch(ctx, client, unit, stats.Add(from, unit, -baseline), end)
+		if err != nil {
+			return ably.ErrorResult(err), nil
+		}
+
+		return ably.ToolResult(anomaliesResult{
+			Unit:      unit,
+			Start:     ably.FormatTimestamp(start.UnixMilli()),
+			End:       ably.FormatTimestamp(end.UnixMilli()),
+			Baseline:  baseline,
+			Threshold: threshold,
+			Anomalies: stats.Anomalies(intervals, metrics, from, baseline, threshold),
+		})
+	}
+}
+
+func CreateStatsanomaliesTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("stats_anomalies",
+		mcp.WithDescription("Flag the intervals of a window in which a metric spiked or dropped: those whose value is more than threshold standard deviations from the mean of the preceding baseline intervals. Metrics that never varied over their baseline are flagged on any change."),
+		mcp.WithString("start", mcp.Description(startDescription)),
+		mcp.WithString("end", mcp.Description(endDescription)),
+		mcp.WithString("unit", mcp.Description(unitDescription)),
+		mcp.WithArray("metrics", mcp.Description(metricsDescription), mcp.WithStringItems()),
+		mcp.WithNumber("baseline", mcp.Description("Number of preceding intervals each interval is compared with. Defaults to 24, at least 3.")),
+		mcp.WithNumber("threshold", mcp.Description("Number of standard deviations from the baseline mean beyond which a value is anomalous. Defaults to 3.")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    StatsanomaliesHandler(cfg),
+	}
+}
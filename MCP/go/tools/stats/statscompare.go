@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
+	"github.com/platform-api/mcp-server/config"
+	"github.com/platform-api/mcp-server/models"
+	"github.com/platform-api/mcp-server/stats"
+)
+
+// compareResult is the result of stats_compare.
+type compareResult struct {
+	Unit          string             `json:"unit"`
+	Start         string             `json:"start"`
+	End           string             `json:"end"`
+	BaselineStart string             `json:"baseline_start"`
+	BaselineEnd   string             `json:"baseline_end"`
+	Metrics       []stats.Comparison `json:"metrics"`
+}
+
+func StatscompareHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		unit, err := stats.UnitArg(args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		now := client.Now()
+		start, end, err := stats.WindowArgs(args, "start", "end", unit, now)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		// The baseline defaults to as many intervals just before the window
+		first := stats.Truncate(start, unit)
+		n := stats.Count(first, stats.Truncate(end, unit), unit)
+		baseStart, baseEnd := stats.Add(first, unit, -n), stats.Add(first, unit, -1)
+		for _, bound := range []struct {
+			name string
+			t    *time.Time
+		}{{"baseline_start", &baseStart}, {"baseline_end", &baseEnd}} {
+			if val, ok := args[bound.name]; ok {
+				if *bound.t, err = ably.ParseTime(val, now); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("invalid parameter %s: %v", bound.name, err)), nil
+				}
+			}
+		}
+		if baseStart.After(baseEnd) {
+			return mcp.NewToolResultError("invalid time range: baseline_start is after baseline_end"), nil
+		}
+		metrics, err := stats.MetricsArg(args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		current, err := stats.Fetch(ctx, client, unit, start, end)
+		if err != nil {
+			return ably.ErrorResult(err), nil
+		}
+		baseline, err := stats.Fetch(ctx, client, unit, baseStart, baseEnd)
+		if err != nil {
+			return ably.ErrorResult(err), nil
+		}
+
+		return ably.ToolResult(compareResult{
+			Unit:          unit,
+			Start:         ably.FormatTimestamp(start.UnixMilli()),
+			End:           ably.FormatTimestamp(end.UnixMilli()),
+			BaselineStart: ably.FormatTimestamp(baseStart.UnixMilli()),
+			BaselineEnd:   ably.FormatTimestamp(baseEnd.UnixMilli()),
+			Metrics:       stats.Compare(current, baseline, metrics),
+		})
+	}
+}
+
+func CreateStatscompareTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("stats_compare",
+		mcp.WithDescription("Compare application stats over a window with a baseline window, e.g. this week against last week: per metric the current and baseline totals, the difference and the percentage change."),
+		mcp.WithString("start", mcp.Description(startDescription)),
+		mcp.WithString("end", mcp.Description(endDescription)),
+		mcp.WithString("baseline_start", mcp.Description("Start of the baseline window, in the same forms as start. Defaults to as many intervals as the window spans, ending just before it.")),
+		mcp.WithString("baseline_end", mcp.Description("End of the baseline window, in the same forms as start. Defaults to the interval before the window.")),
+		mcp.WithString("unit", mcp.Description(unitDescription)),
+		mcp.WithArray("metrics", mcp.Description(metricsDescription), mcp.WithStringItems()),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    StatscompareHandler(cfg),
+	}
+}
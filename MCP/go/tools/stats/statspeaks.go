@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
+	"github.com/platform-api/mcp-server/config"
+	"github.com/platform-api/mcp-server/models"
+	"github.com/platform-api/mcp-server/stats"
+)
+
+// peaksResult is the result of stats_peaks.
+type peaksResult struct {
+	Unit      string               `json:"unit"`
+	Start     string               `json:"start"`
+	End       string               `json:"end"`
+	Intervals int                  `json:"intervals"`
+	Metrics   []stats.Distribution `json:"metrics"`
+}
+
+func StatspeaksHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		unit, err := stats.UnitArg(args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		start, end, err := stats.WindowArgs(args, "start", "end", unit, client.Now())
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		metrics, err := stats.MetricsArg(args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		intervals, err := stats.Fetch(ctx, client, unit, start, end)
+		if err != nil {
+			return ably.ErrorResult(err), nil
+		}
+
+		return ably.ToolResult(peaksResult{
+			Unit:      unit,
+			Start:     ably.FormatTimestamp(start.UnixMilli()),
+			End:       ably.FormatTimestamp(end.UnixMilli()),
+			Intervals: len(intervals),
+			Metrics:   stats.Distribute(intervals, metrics),
+		})
+	}
+}
+
+func CreateStatspeaksTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("stats_peaks",
+		mcp.WithDescription("Find the peak of each metric over a window and the interval it occurred in, with the mean and the 50th, 90th, 95th and 99th percentiles of the per-interval values. Intervals without activity count as zero."),
+		mcp.WithString("start", mcp.Description(startDescription)),
+		mcp.WithString("end", mcp.Description(endDescription)),
+		mcp.WithString("unit", mcp.Description(unitDescription)),
+		mcp.WithArray("metrics", mcp.Description(metricsDescription), mcp.WithStringItems()),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    StatspeaksHandler(cfg),
+	}
+}
@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
+	"github.com/platform-api/mcp-server/config"
+	"github.com/platform-api/mcp-server/models"
+	"github.com/platform-api/mcp-server/stats"
+)
+
+// Descriptions of the parameters shared by the stats analysis tools.
+const (
+	unitDescription    = "Unit of the stats intervals: minute, hour (default), day or month."
+	metricsDescription = "Metrics to analyse: aliases such as messages, inbound_messages, outbound_messages, connections, channels, api_requests, api_requests_failed, push_messages, or counter paths such as inbound.realtime.messages.count. Defaults to the main metrics."
+	startDescription   = "Start of the window: epoch milliseconds, an RFC 3339 time, or a relative time such as -15m, -2h, -7d, today or yesterday. Defaults to 24 intervals before the end."
+	endDescription     = "End of the window, in the same forms as start. Defaults to now."
+)
+
+// summaryResult is the result of stats_summary.
+type summaryResult struct {
+	Unit    string          `json:"unit"`
+	Start   string          `json:"start"`
+	End     string          `json:"end"`
+	Metrics []stats.Summary `json:"metrics"`
+}
+
+func StatssummaryHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		unit, err := stats.UnitArg(args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		start, end, err := stats.WindowArgs(args, "start", "end", unit, client.Now())
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		metrics, err := stats.MetricsArg(args)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		intervals, err := stats.Fetch(ctx, client, unit, start, end)
+		if err != nil {
+			return ably.ErrorResult(err), nil
+		}
+
+		return ably.ToolResult(summaryResult{
+			Unit:    unit,
+			Start:   ably.FormatTimestamp(start.UnixMilli()),
+			End:     ably.FormatTimestamp(end.UnixMilli()),
+			Metrics: stats.Summarize(intervals, metrics),
+		})
+	}
+}
+
+func CreateStatssummaryTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("stats_summary",
+		mcp.WithDescription("Summarise application stats over a window: per metric the total, mean, minimum, maximum and the interval of the peak. Peak, minimum and mean counters such as connections are combined by maximum, minimum and mean rather than summed."),
+		mcp.WithString("start", mcp.Description(startDescription)),
+		mcp.WithString("end", mcp.Description(endDescription)),
+		mcp.WithString("unit", mcp.Description(unitDescription)),
+		mcp.WithArray("metrics", mcp.Description(metricsDescription), mcp.WithStringItems()),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    StatssummaryHandler(cfg),
+	}
+}
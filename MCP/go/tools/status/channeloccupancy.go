@@ -0,0 +1,277 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
+	"github.com/platform-api/mcp-server/config"
+	"github.com/platform-api/mcp-server/models"
+)
+
+// Bounds of an occupancy dashboard.
+const (
+	defaultMaxChannels   = 500
+	maxMaxChannels       = 5000
+	defaultTop           = 10
+	occupancyConcurrency = 8 // channel status requests in flight at once
+)
+
+// occupancyMetrics maps the sort_by values to the occupancy count they sort by.
+var occupancyMetrics = map[string]func(o occupancyRow) int{
+	"connections":          func(o occupancyRow) int { return o.Connections },
+	"publishers":           func(o occupancyRow) int { return o.Publishers },
+	"subscribers":          func(o occupancyRow) int { return o.Subscribers },
+	"presence_members":     func(o occupancyRow) int { return o.PresenceMembers },
+	"presence_connections": func(o occupancyRow) int { return o.PresenceConnections },
+	"presence_subscribers": func(o occupancyRow) int { return o.PresenceSubscribers },
+}
+
+// occupancyRow is the occupancy of one channel.
+type occupancyRow struct {
+	Channel             string `json:"channel"`
+	Active              bool   `json:"active"`
+	Connections         int    `json:"connections"`
+	Publishers          int    `json:"publishers"`
+	Subscribers         int    `json:"subscribers"`
+	PresenceMembers     int    `json:"presence_members"`
+	PresenceConnections int    `json:"presence_connections"`
+	PresenceSubscribers int    `json:"presence_subscribers"`
+	Error               string `json:"error,omitempty"`
+}
+
+// idle reports whether no connection is attached to the channel.
+func (o occupancyRow) idle() bool {
+	return o.Connections == 0 && o.Publishers == 0 && o.Subscribers == 0 && o.PresenceMembers == 0 &&
+		o.PresenceConnections == 0 && o.PresenceSubscribers == 0
+}
+
+// occupancyTotals sums the occupancy of the channels of a dashboard.
+type occupancyTotals struct {
+	Channels            int `json:"channels"`
+	Active              int `json:"active"`
+	Inactive            int `json:"inactive"`
+	Failed              int `json:"failed"`
+	Connections         int `json:"connections"`
+	Publishers          int `json:"publishers"`
+	Subscribers         int `json:"subscribers"`
+	PresenceMembers     int `json:"presence_members"`
+	PresenceConnections int `json:"presence_connections"`
+	PresenceSubscribers int `json:"presence_subscribers"`
+}
+
+// occupancyResult is an occupancy dashboard. Truncated is set when the
+// prefix matches more than max_channels channels.
+type occupancyResult struct {
+	Prefix    string          `json:"prefix,omitempty"`
+	SortBy    string          `json:"sort_by"`
+	Totals    occupancyTotals `json:"totals"`
+	Top       []occupancyRow  `json:"top"`
+	Inactive  []string        `json:"inactive"`
+	Channels  []occupancyRow  `json:"channels"`
+	Truncated bool            `json:"truncated"`
+}
+
+func ChanneloccupancyHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args, ok := request.Params.Arguments.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("Invalid arguments object"), nil
+		}
+		result := occupancyResult{SortBy: "connections", Top: []occupancyRow{}, Inactive: []string{}}
+		query := url.Values{}
+		if val, ok := args["prefix"]; ok {
+			prefix, ok := val.(string)
+			if !ok {
+				return mcp.NewToolResultError("invalid parameter: prefix must be a string"), nil
+			}
+			result.Prefix = prefix
+			query.Set("prefix", prefix)
+		}
+		if val, ok := args["sort_by"]; ok {
+			sortBy, _ := val.(string)
+			if occupancyMetrics[sortBy] == nil {
+				return mcp.NewToolResultError("invalid parameter: sort_by must be connections, publishers, subscribers, presence_members, presence_connections or presence_subscribers"), nil
+			}
+			result.SortBy = sortBy
+		}
+		top, err := ably.CountArg(args, "top", defaultTop, maxMaxChannels)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		maxChannels, err := ably.CountArg(args, "max_channels", defaultMaxChannels, maxMaxChannels)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+
+		names, truncated, err := listChannels(ctx, client, query, maxChannels)
+		if err != nil {
+			return ably.ErrorResult(err), nil
+		}
+		result.Truncated = truncated
+
+		rows, err := channelOccupancies(ctx, client, names)
+		if err != nil {
+			return ably.ErrorResult(err), nil
+		}
+
+		metric := occupancyMetrics[result.SortBy]
+		sort.SliceStable(rows, func(i, j int) bool {
+			if a, b := metric(rows[i]), metric(rows[j]); a != b {
+				return a > b
+			}
+			return rows[i].Channel < rows[j].Channel
+		})
+		result.Channels = rows
+		result.Totals.Channels = len(rows)
+		for _, row := range rows {
+			switch {
+			case row.Error != "":
+				result.Totals.Failed++
+				continue
+			case !row.Active || row.idle():
+				result.Totals.Inactive++
+				result.Inactive = append(result.Inactive, row.Channel)
+			default:
+				result.Totals.Active++
+				if len(result.Top) < top && metric(row) > 0 {
+					result.Top = append(result.Top, row)
+				}
+			}
+			result.Totals.Connections += row.Connections
+			result.Totals.Publishers += row.Publishers
+			result.Totals.Subscribers += row.Subscribers
+			result.Totals.PresenceMembers += row.PresenceMembers
+			result.Totals.PresenceConnections += row.PresenceConnections
+			result.Totals.PresenceSubscribers += row.PresenceSubscribers
+		}
+		sort.Strings(result.Inactive)
+
+		return ably.ToolResult(result)
+	}
+}
+
+// listChannels returns the names of up to limit channels matching query,
+// following next links for up to ably.MaxPages pages, and whether more
+// channels may match.
+func listChannels(ctx context.Context, client *ably.Client, query url.Values, limit int) ([]string, bool, error) {
+	req := &ably.Request{
+		Method: http.MethodGet,
+		Path:   ably.Path("channels"),
+		Query:  query,
+	}
+	var names []string
+	for range ably.MaxPages {
+		// One channel more than needed tells whether the listing is complete
+		req.Query.Set("by", "id")
+		req.Query.Set("limit", strconv.Itoa(min(ably.MaxPageSize, limit+1-len(names))))
+		resp, err := client.Do(ctx, req)
+		if err != nil {
+			return nil, false, err
+		}
+		var page []string
+		if err := resp.Decode(&page); err != nil {
+			return nil, false, fmt.Errorf("failed to decode channel list: %w", err)
+		}
+		names = append(names, page...)
+		if len(names) > limit {
+			return names[:limit], true, nil
+		}
+		cursor := resp.NextCursor()
+		if cursor == "" {
+			return names, false, nil
+		}
+		if err := req.ApplyCursor(cursor); err != nil {
+			return nil, false, err
+		}
+	}
+	return names, true, nil
+}
+
+// channelOccupancies fetches the status of each channel, a few at a time.
+// A channel that fails is reported with its error, unless every channel
+// fails, which suggests a problem with the request rather than a channel.
+func channelOccupancies(ctx context.Context, client *ably.Client, names []string) ([]occupancyRow, error) {
+	rows := make([]occupancyRow, len(names))
+	errs := make([]error, len(names))
+	sem := make(chan struct{}, occupancyConcurrency)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			rows[i], errs[i] = channelOccupancy(ctx, client, name)
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			rows[i].Error = err.Error()
+			failed++
+		}
+	}
+	if failed > 0 && failed == len(names) {
+		return nil, errs[0]
+	}
+	return rows, nil
+}
+
+// channelOccupancy fetches the status of a channel. A channel that closed
+// since it was listed is reported as inactive.
+func channelOccupancy(ctx context.Context, client *ably.Client, name string) (occupancyRow, error) {
+	row := occupancyRow{Channel: name}
+	resp, err := client.Do(ctx, &ably.Request{
+		Method: http.MethodGet,
+		Path:   ably.Path("channels", name),
+	})
+	var apiErr *ably.Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return row, nil
+	}
+	if err != nil {
+		return row, err
+	}
+	var details models.ChannelDetails
+	if err := resp.Decode(&details); err != nil {
+		return row, fmt.Errorf("failed to decode channel details: %w", err)
+	}
+	o := details.Status.Occupancy
+	row.Active = details.Status.Isactive
+	row.Connections = o.Connections
+	row.Publishers = o.Publishers
+	row.Subscribers = o.Subscribers
+	row.PresenceMembers = o.Presencemembers
+	row.PresenceConnections = o.Presenceconnections
+	row.PresenceSubscribers = o.Presencesubscribers
+	return row, nil
+}
+
+func CreateChanneloccupancyTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("get_channel_occupancy",
+		mcp.WithDescription("Occupancy dashboard of the channels under a prefix: enumerates the channels, fetches the status of each and returns them sorted by occupancy, with totals, the busiest channels and the inactive ones (not active, or without any attached connection)."),
+		mcp.WithString("prefix", mcp.Description("Only include channels whose name starts with this prefix. Defaults to all channels.")),
+		mcp.WithString("sort_by", mcp.Description("Occupancy count to sort and rank by: connections (default, the attached connections as Ably counts them), publishers, subscribers, presence_members, presence_connections or presence_subscribers.")),
+		mcp.WithNumber("top", mcp.Description("Number of busiest channels to list in top. Defaults to 10.")),
+		mcp.WithNumber("max_channels", mcp.Description("Maximum number of channels to include. Defaults to 500, at most 5000; truncated is set when more channels match.")),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    ChanneloccupancyHandler(cfg),
+	}
+}
@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
+	"github.com/platform-api/mcp-server/config"
+	"github.com/platform-api/mcp-server/models"
+)
+
+// diagnosticsResult describes how the server talks to Ably, as observed by
+// a request for the service time. NegotiatedVersion is only known, and
+// VersionConfirmed only set, when Ably echoes X-Ably-Version.
+type diagnosticsResult struct {
+	BaseURL           string        `json:"base_url"`
+	AuthMethod        string        `json:"auth_method"`
+	WireFormat        string        `json:"wire_format"`
+	RequestedVersion  string        `json:"requested_version,omitempty"`
+	NegotiatedVersion string        `json:"negotiated_version,omitempty"`
+	VersionConfirmed  bool          `json:"version_confirmed"`
+	Reachable         bool          `json:"reachable"`
+	ServerID          string        `json:"server_id,omitempty"`
+	ResponseFormat    string        `json:"response_format,omitempty"`
+	LatencyMs         int64         `json:"latency_ms"`
+	Error             *models.Error `json:"error,omitempty"`
+}
+
+func GetdiagnosticsHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result := diagnosticsResult{
+			BaseURL:          cfg.BaseURL,
+			AuthMethod:       client.AuthMethod(),
+			WireFormat:       cfg.Format,
+			RequestedVersion: cfg.APIVersion,
+		}
+		if result.WireFormat == "" {
+			result.WireFormat = config.FormatJSON
+		}
+
+		start := time.Now()
+		resp, err := client.Do(ctx, &ably.Request{
+			Method: http.MethodGet,
+			Path:   ably.Path("time"),
+		})
+		result.LatencyMs = time.Since(start).Milliseconds()
+
+		var header http.Header
+		var apiErr *ably.Error
+		switch {
+		case err == nil:
+			header = resp.Header
+			result.Reachable = true
+		case errors.As(err, &apiErr):
+			// Ably answered, so the rejection itself is the diagnosis
+			header = apiErr.Header
+			result.Reachable = true
+			result.Error = &apiErr.Info
+		default:
+			return ably.ErrorResult(err), nil
+		}
+
+		result.NegotiatedVersion = header.Get("X-Ably-Version")
+		result.VersionConfirmed = result.NegotiatedVersion != ""
+		result.ServerID = header.Get("X-Ably-Serverid")
+		result.ResponseFormat = header.Get("Content-Type")
+
+		return ably.ToolResult(result)
+	}
+}
+
+func CreateGetdiagnosticsTool(cfg *config.APIConfig) models.Tool {
+	tool := mcp.NewTool("get_diagnostics",
+		mcp.WithDescription("Report how this server connects to Ably: endpoint, authentication method, wire format and the X-Ably-Version Ably confirms using, if it echoes one, checked with a request for the service time"),
+	)
+
+	return models.Tool{
+		Definition: tool,
+		Handler:    GetdiagnosticsHandler(cfg),
+	}
+}
@@ -2,75 +2,55 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
-	"strings"
+	"net/url"
 
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/platform-api/mcp-server/ably"
 	"github.com/platform-api/mcp-server/config"
 	"github.com/platform-api/mcp-server/models"
-	"github.com/mark3labs/mcp-go/mcp"
 )
 
 func GetmetadataofallchannelsHandler(cfg *config.APIConfig) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	client := ably.NewClient(cfg)
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		args, ok := request.Params.Arguments.(map[string]any)
 		if !ok {
 			return mcp.NewToolResultError("Invalid arguments object"), nil
 		}
-		queryParams := make([]string, 0)
+		query := url.Values{}
 		if val, ok := args["limit"]; ok {
-			queryParams = append(queryParams, fmt.Sprintf("limit=%v", val))
+			query.Set("limit", ably.FormatParam(val))
 		}
 		if val, ok := args["prefix"]; ok {
-			queryParams = append(queryParams, fmt.Sprintf("prefix=%v", val))
+			query.Set("prefix", ably.FormatParam(val))
 		}
 		if val, ok := args["by"]; ok {
-			queryParams = append(queryParams, fmt.Sprintf("by=%v", val))
-		}
-		queryString := ""
-		if len(queryParams) > 0 {
-			queryString = "?" + strings.Join(queryParams, "&")
-		}
-		url := fmt.Sprintf("%s/channels%s", cfg.BaseURL, queryString)
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to create request", err), nil
-		}
-		// Set authentication based on auth type
-		if cfg.BasicAuth != "" {
-			req.Header.Set("Authorization", fmt.Sprintf("Basic %s", cfg.BasicAuth))
+			query.Set("by", ably.FormatParam(val))
 		}
-		req.Header.Set("Accept", "application/json")
-
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Request failed", err), nil
-		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
+		cursor, maxPages, err := ably.PaginationArgs(args)
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to read response body", err), nil
+			return mcp.NewToolResultError(err.Error()), nil
 		}
-
-		if resp.StatusCode >= 400 {
-			return mcp.NewToolResultError(fmt.Sprintf("API error: %s", body)), nil
+		req := &ably.Request{
+			Method: http.MethodGet,
+			Path:   ably.Path("channels"),
+			Query:  query,
 		}
-		// Use properly typed response
-		var result models.Error
-		if err := json.Unmarshal(body, &result); err != nil {
-			// Fallback to raw text if unmarshaling fails
-			return mcp.NewToolResultText(string(body)), nil
+		// by=id returns channel names, by=value returns ChannelDetails
+		if args["by"] == "id" {
+			result, nextCursor, err := ably.Paginate[string](ctx, client, req, cursor, maxPages)
+			if err != nil {
+				return ably.ErrorResult(err), nil
+			}
+			return ably.ToolPageResult(result, nextCursor)
 		}
-
-		prettyJSON, err := json.MarshalIndent(result, "", "  ")
+		result, nextCursor, err := ably.Paginate[models.ChannelDetails](ctx, client, req, cursor, maxPages)
 		if err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to format JSON", err), nil
+			return ably.ErrorResult(err), nil
 		}
 
-		return mcp.NewToolResultText(string(prettyJSON)), nil
+		return ably.ToolPageResult(result, nextCursor)
 	}
 }
 
@@ -80,6 +60,8 @@ func CreateGetmetadataofallchannelsTool(cfg *config.APIConfig) models.Tool {
 		mcp.WithNumber("limit", mcp.Description("")),
 		mcp.WithString("prefix", mcp.Description("Optionally limits the query to only those channels whose name starts with the given prefix")),
 		mcp.WithString("by", mcp.Description("optionally specifies whether to return just channel names (by=id) or ChannelDetails (by=value)")),
+		mcp.WithString("cursor", mcp.Description("Opaque cursor returned as next_cursor by a previous call, to fetch the following page.")),
+		mcp.WithNumber("max_pages", mcp.Description("Number of pages to fetch and concatenate by following next links. Defaults to 1, at most 100.")),
 	)
 
 	return models.Tool{